@@ -7,8 +7,13 @@ import (
 	"compress/gzip"
 	"errors"
 	"io"
-	"io/ioutil"
-	"regexp"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
 )
 
 // ArchiveFormat enumerates archive formats.
@@ -19,63 +24,196 @@ const (
 	Zip = iota
 	// TarGz is the tar.gz file format.
 	TarGz
+	// TarZst is the tar.zst file format.
+	TarZst
 )
 
 var (
 	// ErrArchiveUnknown is returned when an invalid archive format is specified
 	ErrArchiveUnknown = errors.New("unknown archive format")
+	// ErrUnsafePath is returned when an archive entry path is absolute or
+	// escapes the archive root
+	ErrUnsafePath = errors.New("unsafe path")
+	// ErrLinkTarget is returned when the target of a link is not found in the
+	// archive
+	ErrLinkTarget = errors.New("link target not found")
+	// ErrLinkLoop is returned when links in the archive form a loop
+	ErrLinkLoop = errors.New("too many levels of links")
+	// ErrMemberNotFound is returned when a nested archive is not found in the
+	// archive
+	ErrMemberNotFound = errors.New("archive member not found")
 )
 
+// Entry describes a file passed to an EntryMapper.
+type Entry struct {
+	Path    string
+	Size    int64
+	Mode    os.FileMode
+	ModTime time.Time
+}
+
+// EntryMapper is like PathMapper, but it receives the metadata of each file
+// besides its path. If "" is returned, the file is dropped.
+type EntryMapper func(Entry) string
+
 // PathMapper specifies a function that is executed on all files in the archive.
 // The mapper receives the full path to each file in the archive and returns
 // the path to use in the asset file system. If "" is returned, the file is
 // dropped.
+//
+// Paths are cleaned before they are passed to the mapper. Paths that are
+// absolute or escape the archive root are passed unmodified, and the mapper
+// must either drop them or map them to a safe path.
 type PathMapper func(string) string
 
-// ReMap returns a PathMapper that compares file paths to the input pattern
-// and maps matches to the replacement string (see Regexp.ReplaceAllString).
-func ReMap(pattern string, replacement string) PathMapper {
-	re := regexp.MustCompile(pattern)
-	return func(filePath string) string {
-		if !re.MatchString(filePath) {
-			return ""
-		}
-		return re.ReplaceAllString(filePath, replacement)
-	}
-}
-
 // Archive describes an archive format for the asset source.
 type Archive struct {
 	Format     ArchiveFormat
 	PathMapper PathMapper
+
+	// EntryMapper is executed on all files in the archive after PathMapper,
+	// and receives the path returned by PathMapper.
+	EntryMapper EntryMapper
+
+	// StripComponents is the number of leading path components removed from
+	// the file paths in the archive before they are passed to the PathMapper.
+	// Files with fewer path components are dropped. If set to AutoStrip, the
+	// top-level directory is removed if it is the only one in the archive.
+	StripComponents int
+
+	// Mount places the extracted files under Source.Path. By default the
+	// files are stored at their (mapped) paths in the archive.
+	Mount bool
+
+	// ResolveLinks stores symbolic and hard links in the archive as files
+	// with the contents of their targets. By default links are dropped.
+	ResolveLinks bool
+
+	// Nested specifies an archive stored inside this archive. If set, only
+	// the files extracted from the nested archive are kept.
+	Nested *Nested
+}
+
+// Nested describes an archive stored inside another archive.
+type Nested struct {
+	// Member is the path of the nested archive in the outer archive, as
+	// returned by the PathMapper of the outer archive.
+	Member string
+
+	// Checksum is the optional checksum of the nested archive.
+	Checksum *Checksum
+
+	// Archive describes the format of the nested archive.
+	Archive *Archive
 }
 
-func processArchive(arch *Archive, data []byte) ([]*file, error) {
+// AutoStrip can be set in Archive.StripComponents to remove a single
+// top-level directory from the file paths in the archive.
+const AutoStrip = -1
+
+// maxLinks is the maximum number of links followed when resolving a link.
+const maxLinks = 40
+
+// entry is a file or a link extracted from an archive.
+type entry struct {
+	file
+	link     string
+	hardLink bool
+}
+
+func processArchive(arch *Archive, data []byte, opts *Opts) ([]*file, error) {
+	if opts == nil {
+		opts = &Opts{}
+	}
+
+	return extractArchive(arch, data, opts, newLimiter(opts.Limits, len(data)))
+}
+
+// extractArchive extracts the files from the archive and its nested archives.
+// The limiter is shared by the nested archives, so the limits apply to the
+// whole extraction.
+func extractArchive(arch *Archive, data []byte, opts *Opts, l *limiter) ([]*file, error) {
+	var entries []*entry
+	var err error
+
 	switch arch.Format {
 	case Zip:
-		return processZip(arch, data)
+		entries, err = processZip(data, l)
 	case TarGz:
-		return processTarGz(arch, data)
+		entries, err = processTarGz(data, l)
+	case TarZst:
+		entries, err = processTarZst(data, l)
 	default:
 		return nil, ErrArchiveUnknown
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	files, linkErrs := resolveLinks(entries, arch.ResolveLinks)
+
+	if !opts.KeepDirs {
+		files = dropDirs(files)
+	}
+
+	files, err = mapFiles(arch, files)
+	if err != nil {
+		return nil, err
+	}
+
+	// Links that cannot be resolved are only errors if they are kept
+	for _, f := range files {
+		if err := linkErrs[f]; err != nil {
+			return nil, err
+		}
+	}
+
+	if arch.Nested == nil {
+		return files, nil
+	}
+
+	return processNested(arch.Nested, files, opts, l)
 }
 
-func processZip(arch *Archive, data []byte) ([]*file, error) {
+func processNested(nested *Nested, files []*file, opts *Opts, l *limiter) ([]*file, error) {
+	for _, f := range files {
+		if f.path != nested.Member || f.mode.IsDir() {
+			continue
+		}
+
+		if nested.Checksum != nil {
+			err := verifyChecksum(nested.Checksum, f.data)
+			if err != nil {
+				return nil, &ChecksumError{nested.Member, err}
+			}
+		}
+
+		nestedFiles, err := extractArchive(nested.Archive, f.data, opts, l)
+		if err != nil {
+			return nil, &ArchiveError{nested.Member, err}
+		}
+
+		return nestedFiles, nil
+	}
+
+	return nil, &ArchiveError{nested.Member, ErrMemberNotFound}
+}
+
+func processZip(data []byte, l *limiter) ([]*entry, error) {
 	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
 	if err != nil {
 		return nil, err
 	}
 
-	files := []*file{}
+	entries := []*entry{}
 
 	for _, fh := range r.File {
-		if fh.FileInfo().IsDir() {
-			continue
+		if err := l.entry(); err != nil {
+			return nil, err
 		}
 
-		fp := mapPath(arch.PathMapper, fh.Name)
-		if fp == "" {
+		if fh.FileInfo().IsDir() {
+			entries = append(entries, &entry{file{fh.Name, nil, fh.ModTime(), fh.Mode() | os.ModeDir}, "", false})
 			continue
 		}
 
@@ -84,25 +222,45 @@ func processZip(arch *Archive, data []byte) ([]*file, error) {
 			return nil, err
 		}
 
-		fdata, err := ioutil.ReadAll(fr)
+		fdata, err := l.read(fr)
+		fr.Close()
 		if err != nil {
 			return nil, err
 		}
 
-		files = append(files, &file{fp, fdata, fh.ModTime()})
+		if fh.Mode()&os.ModeSymlink != 0 {
+			entries = append(entries, &entry{file{fh.Name, nil, fh.ModTime(), 0}, string(fdata), false})
+			continue
+		}
+
+		entries = append(entries, &entry{file{fh.Name, fdata, fh.ModTime(), fh.Mode()}, "", false})
 	}
 
-	return files, nil
+	return entries, nil
 }
 
-func processTarGz(arch *Archive, data []byte) ([]*file, error) {
+func processTarGz(data []byte, l *limiter) ([]*entry, error) {
 	zr, err := gzip.NewReader(bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
 
-	r := tar.NewReader(zr)
-	files := []*file{}
+	return processTar(zr, l)
+}
+
+func processTarZst(data []byte, l *limiter) ([]*entry, error) {
+	zr, err := zstd.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return processTar(zr, l)
+}
+
+func processTar(tr io.Reader, l *limiter) ([]*entry, error) {
+	r := tar.NewReader(tr)
+	entries := []*entry{}
 
 	for {
 		h, err := r.Next()
@@ -113,30 +271,290 @@ func processTarGz(arch *Archive, data []byte) ([]*file, error) {
 			return nil, err
 		}
 
-		if h.Typeflag != tar.TypeReg && h.Typeflag != tar.TypeRegA {
+		if err := l.entry(); err != nil {
+			return nil, err
+		}
+
+		switch h.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+			fdata, err := l.read(r)
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, &entry{file{h.Name, fdata, h.ModTime, h.FileInfo().Mode()}, "", false})
+		case tar.TypeDir:
+			entries = append(entries, &entry{file{h.Name, nil, h.ModTime, h.FileInfo().Mode()}, "", false})
+		case tar.TypeSymlink:
+			entries = append(entries, &entry{file{h.Name, nil, h.ModTime, 0}, h.Linkname, false})
+		case tar.TypeLink:
+			entries = append(entries, &entry{file{h.Name, nil, h.ModTime, 0}, h.Linkname, true})
+		}
+	}
+
+	return entries, nil
+}
+
+// dropDirs removes the directories from the files extracted from the archive.
+func dropDirs(files []*file) []*file {
+	kept := []*file{}
+	for _, f := range files {
+		if !f.mode.IsDir() {
+			kept = append(kept, f)
+		}
+	}
+
+	return kept
+}
+
+// resolveLinks returns the files extracted from the archive. If resolve is
+// true, links are replaced with files with the contents of their targets
+// (or with the files of their target directories), and the errors of the links that cannot be resolved are returned for their
+// files. Otherwise links are dropped.
+func resolveLinks(entries []*entry, resolve bool) ([]*file, map[*file]error) {
+	targets := map[string]*entry{}
+	for _, e := range entries {
+		if fp, safe := cleanPath(e.path); safe {
+			targets[fp] = e
+		}
+	}
+
+	files := []*file{}
+	errs := map[*file]error{}
+
+	for _, e := range entries {
+		if e.link == "" {
+			files = append(files, &e.file)
 			continue
 		}
 
-		fp := mapPath(arch.PathMapper, h.Name)
-		if fp == "" {
+		if !resolve {
 			continue
 		}
 
-		fdata, err := ioutil.ReadAll(r)
+		files = append(files, linkFiles(targets, e, e.path, nil, errs)...)
+	}
+
+	return files, errs
+}
+
+// linkFiles returns the files of a link stored at the path: a file with the
+// contents of the target file, or a directory with the files of the target
+// directory. The directories already being expanded are listed in parents,
+// so links to them are reported as loops.
+func linkFiles(targets map[string]*entry, e *entry, name string, parents []string, errs map[*file]error) []*file {
+	t, err := resolveLink(targets, e)
+	if err != nil {
+		f := &file{name, nil, e.modTime, 0}
+		errs[f] = err
+		return []*file{f}
+	}
+
+	if !t.mode.IsDir() {
+		return []*file{{name, t.data, e.modTime, t.mode}}
+	}
+
+	dir, _ := cleanPath(t.path)
+	for _, p := range parents {
+		if p == dir {
+			f := &file{name, nil, e.modTime, 0}
+			errs[f] = &ArchiveError{e.path, ErrLinkLoop}
+			return []*file{f}
+		}
+	}
+	parents = append(parents, dir)
+
+	if fp, safe := cleanPath(name); safe {
+		name = fp
+	}
+
+	paths := []string{}
+	for fp := range targets {
+		if strings.HasPrefix(fp, dir+"/") {
+			paths = append(paths, fp)
+		}
+	}
+	sort.Strings(paths)
+
+	files := []*file{{name, nil, e.modTime, t.mode}}
+	for _, fp := range paths {
+		c := targets[fp]
+		cname := name + strings.TrimPrefix(fp, dir)
+		if c.link == "" {
+			files = append(files, &file{cname, c.data, c.modTime, c.mode})
+			continue
+		}
+		files = append(files, linkFiles(targets, c, cname, parents, errs)...)
+	}
+
+	return files
+}
+
+// resolveLink follows a link until a file is found.
+func resolveLink(targets map[string]*entry, e *entry) (*entry, error) {
+	name := e.path
+
+	for i := 0; i < maxLinks; i++ {
+		if e.link == "" {
+			return e, nil
+		}
+
+		target := e.link
+		if !e.hardLink && !strings.HasPrefix(target, "/") {
+			fp, _ := cleanPath(e.path)
+			target = path.Join(path.Dir(fp), target)
+		}
+
+		fp, safe := cleanPath(target)
+		if !safe {
+			return nil, &ArchiveError{name, ErrUnsafePath}
+		}
+
+		t, ok := targets[fp]
+		if !ok && hasChildren(targets, fp) {
+			// Directories without an entry of their own
+			t = &entry{file{fp, nil, e.modTime, os.ModeDir | 0755}, "", false}
+		} else if !ok {
+			return nil, &ArchiveError{name, ErrLinkTarget}
+		}
+
+		e = t
+	}
+
+	return nil, &ArchiveError{name, ErrLinkLoop}
+}
+
+// hasChildren reports whether there are entries in the directory.
+func hasChildren(targets map[string]*entry, dir string) bool {
+	for fp := range targets {
+		if strings.HasPrefix(fp, dir+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mapFiles maps the paths of the files extracted from the archive, and drops
+// the files that are not needed.
+func mapFiles(arch *Archive, files []*file) ([]*file, error) {
+	strip := arch.StripComponents
+	if strip == AutoStrip {
+		strip = detectStrip(files)
+	}
+
+	mapped := []*file{}
+
+	for _, f := range files {
+		fp, err := mapPath(arch, strip, f)
 		if err != nil {
 			return nil, err
 		}
+		if fp == "" {
+			continue
+		}
+
+		f.path = fp
+		mapped = append(mapped, f)
+	}
+
+	return mapped, nil
+}
+
+func mapPath(arch *Archive, strip int, f *file) (string, error) {
+	name := f.path
+	fp, safe := cleanPath(name)
+	if !safe && f.mode.IsDir() && path.Clean(name) == "." {
+		// The root directory of the archive, such as "./"
+		return "", nil
+	}
+	if safe {
+		name = stripPath(fp, strip)
+		if name == "" {
+			return "", nil
+		}
+		fp = name
+	}
+
+	if arch.PathMapper != nil {
+		fp = arch.PathMapper(fp)
+		if fp == "" {
+			return "", nil
+		}
+		fp, safe = cleanPath(fp)
+	}
+
+	if arch.EntryMapper != nil {
+		fp = arch.EntryMapper(Entry{fp, int64(len(f.data)), f.mode, f.modTime})
+		if fp == "" {
+			return "", nil
+		}
+		fp, safe = cleanPath(fp)
+	}
+
+	if !safe {
+		return "", &ArchiveError{name, ErrUnsafePath}
+	}
+
+	return fp, nil
+}
+
+// cleanPath cleans an archive entry path, and reports whether the result is
+// a relative path that stays inside the archive root.
+func cleanPath(name string) (string, bool) {
+	name = strings.Replace(name, "\\", "/", -1)
+	if strings.HasPrefix(name, "/") || (len(name) > 1 && name[1] == ':') {
+		return name, false
+	}
+
+	fp := path.Clean(name)
+	if fp == "." || fp == ".." || strings.HasPrefix(fp, "../") {
+		return name, false
+	}
+
+	return fp, true
+}
 
-		files = append(files, &file{fp, fdata, h.ModTime})
+// stripPath removes the leading path components from a clean path. If the
+// path has no more components left, "" is returned.
+func stripPath(fp string, strip int) string {
+	for ; strip > 0; strip-- {
+		i := strings.Index(fp, "/")
+		if i < 0 {
+			return ""
+		}
+		fp = fp[i+1:]
 	}
 
-	return files, nil
+	return fp
 }
 
-func mapPath(mapper PathMapper, path string) string {
-	if mapper == nil {
-		return path
+// detectStrip returns 1 if all files are located in the same top-level
+// directory, and 0 otherwise.
+func detectStrip(files []*file) int {
+	top := ""
+
+	for _, f := range files {
+		fp, safe := cleanPath(f.path)
+		if !safe || f.mode.IsDir() {
+			continue
+		}
+
+		i := strings.Index(fp, "/")
+		if i < 0 {
+			return 0
+		}
+
+		if top == "" {
+			top = fp[:i]
+		} else if top != fp[:i] {
+			return 0
+		}
+	}
+
+	if top == "" {
+		return 0
 	}
 
-	return mapper(path)
+	return 1
 }
@@ -5,10 +5,13 @@ import (
 	"archive/zip"
 	"bytes"
 	"compress/gzip"
+	"encoding/hex"
 	"io"
 	"os"
 	"testing"
 	"time"
+
+	"github.com/klauspost/compress/zstd"
 )
 
 func TestArchiveZip(t *testing.T) {
@@ -39,13 +42,13 @@ func TestArchiveZip(t *testing.T) {
 
 	w.Close()
 
-	files, err := processArchive(&Archive{Zip, nil}, buf.Bytes())
+	files, err := processArchive(&Archive{Format: Zip}, buf.Bytes(), nil)
 	assertEqual(t, err, nil)
 
 	assertEqual(t, len(files), 3)
-	assertEqual(t, files[0], &file{"test/file1.txt", []byte("File 1"), mt1.UTC()})
-	assertEqual(t, files[1], &file{"test/file2.txt", []byte("File 2"), mt2.UTC()})
-	assertEqual(t, files[2], &file{"test/file3.txt", []byte("File 3"), mt3.UTC()})
+	assertEqual(t, files[0], &file{"test/file1.txt", []byte("File 1"), mt1.UTC(), 0666})
+	assertEqual(t, files[1], &file{"test/file2.txt", []byte("File 2"), mt2.UTC(), 0666})
+	assertEqual(t, files[2], &file{"test/file3.txt", []byte("File 3"), mt3.UTC(), 0666})
 }
 
 func TestArchiveZipFilter(t *testing.T) {
@@ -85,12 +88,12 @@ func TestArchiveZipFilter(t *testing.T) {
 		}
 	}
 
-	files, err := processArchive(&Archive{Zip, mapper}, buf.Bytes())
+	files, err := processArchive(&Archive{Format: Zip, PathMapper: mapper}, buf.Bytes(), nil)
 	assertEqual(t, err, nil)
 
 	assertEqual(t, len(files), 2)
-	assertEqual(t, files[0], &file{"test/file1.txt", []byte("File 1"), mt1.UTC()})
-	assertEqual(t, files[1], &file{"test/file2.txt", []byte("File 2"), mt2.UTC()})
+	assertEqual(t, files[0], &file{"test/file1.txt", []byte("File 1"), mt1.UTC(), 0666})
+	assertEqual(t, files[1], &file{"test/file2.txt", []byte("File 2"), mt2.UTC(), 0666})
 }
 
 func TestArchiveZipReMap(t *testing.T) {
@@ -121,16 +124,16 @@ func TestArchiveZipReMap(t *testing.T) {
 
 	w.Close()
 
-	files, err := processArchive(&Archive{Zip, ReMap("(test/file[12].txt)", "${1}")}, buf.Bytes())
+	files, err := processArchive(&Archive{Format: Zip, PathMapper: ReMap("(test/file[12].txt)", "${1}")}, buf.Bytes(), nil)
 	assertEqual(t, err, nil)
 
 	assertEqual(t, len(files), 2)
-	assertEqual(t, files[0], &file{"test/file1.txt", []byte("File 1"), mt1.UTC()})
-	assertEqual(t, files[1], &file{"test/file2.txt", []byte("File 2"), mt2.UTC()})
+	assertEqual(t, files[0], &file{"test/file1.txt", []byte("File 1"), mt1.UTC(), 0666})
+	assertEqual(t, files[1], &file{"test/file2.txt", []byte("File 2"), mt2.UTC(), 0666})
 }
 
 func TestArchiveZipInvalid(t *testing.T) {
-	_, err := processArchive(&Archive{Zip, nil}, []byte("1234"))
+	_, err := processArchive(&Archive{Format: Zip}, []byte("1234"), nil)
 	assertEqual(t, err, zip.ErrFormat)
 }
 
@@ -160,13 +163,13 @@ func TestArchiveTarGz(t *testing.T) {
 	w.Close()
 	zw.Close()
 
-	files, err := processArchive(&Archive{TarGz, nil}, buf.Bytes())
+	files, err := processArchive(&Archive{Format: TarGz}, buf.Bytes(), nil)
 	assertEqual(t, err, nil)
 
 	assertEqual(t, len(files), 3)
-	assertEqual(t, files[0], &file{"test/file1.txt", []byte("File 1"), mt1})
-	assertEqual(t, files[1], &file{"test/file2.txt", []byte("File 2"), mt2})
-	assertEqual(t, files[2], &file{"test/file3.txt", []byte("File 3"), mt3})
+	assertEqual(t, files[0], &file{"test/file1.txt", []byte("File 1"), mt1, 0})
+	assertEqual(t, files[1], &file{"test/file2.txt", []byte("File 2"), mt2, 0})
+	assertEqual(t, files[2], &file{"test/file3.txt", []byte("File 3"), mt3, 0})
 }
 
 func TestArchiveTarGzFilter(t *testing.T) {
@@ -204,20 +207,485 @@ func TestArchiveTarGzFilter(t *testing.T) {
 		}
 	}
 
-	files, err := processArchive(&Archive{TarGz, mapper}, buf.Bytes())
+	files, err := processArchive(&Archive{Format: TarGz, PathMapper: mapper}, buf.Bytes(), nil)
 	assertEqual(t, err, nil)
 
 	assertEqual(t, len(files), 2)
-	assertEqual(t, files[0], &file{"test/file1.txt", []byte("File 1"), mt1})
-	assertEqual(t, files[1], &file{"test/file2.txt", []byte("File 2"), mt2})
+	assertEqual(t, files[0], &file{"test/file1.txt", []byte("File 1"), mt1, 0})
+	assertEqual(t, files[1], &file{"test/file2.txt", []byte("File 2"), mt2, 0})
 }
 
 func TestArchiveTarGzInvalid(t *testing.T) {
-	_, err := processArchive(&Archive{TarGz, nil}, []byte("1234"))
+	_, err := processArchive(&Archive{Format: TarGz}, []byte("1234"), nil)
 	assertEqual(t, err, io.ErrUnexpectedEOF)
 }
 
 func TestArchiveUnknown(t *testing.T) {
-	_, err := processArchive(&Archive{-1, nil}, []byte("Test"))
+	_, err := processArchive(&Archive{Format: -1}, []byte("Test"), nil)
 	assertEqual(t, err, ErrArchiveUnknown)
 }
+
+func TestArchiveTarZst(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zw, _ := zstd.NewWriter(buf)
+	w := tar.NewWriter(zw)
+
+	mt1 := time.Unix(1300000000, 0)
+	fh1 := &tar.Header{Name: "test/file1.txt", Size: int64(6), ModTime: mt1}
+	w.WriteHeader(fh1)
+	w.Write([]byte("File 1"))
+
+	mt2 := time.Unix(1400000000, 0)
+	fh2 := &tar.Header{Name: "test/file2.txt", Size: int64(6), ModTime: mt2}
+	w.WriteHeader(fh2)
+	w.Write([]byte("File 2"))
+
+	dh := &tar.Header{Name: "test/dir", Typeflag: tar.TypeDir}
+	w.WriteHeader(dh)
+
+	w.Close()
+	zw.Close()
+
+	files, err := processArchive(&Archive{Format: TarZst}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+
+	assertEqual(t, len(files), 2)
+	assertEqual(t, files[0], &file{"test/file1.txt", []byte("File 1"), mt1, 0})
+	assertEqual(t, files[1], &file{"test/file2.txt", []byte("File 2"), mt2, 0})
+}
+
+func TestArchiveTarZstInvalid(t *testing.T) {
+	_, err := processArchive(&Archive{Format: TarZst}, []byte("1234"), nil)
+	assertEqual(t, err, zstd.ErrMagicMismatch)
+}
+
+func TestArchiveZipUnsafePath(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+
+	fh1 := &zip.FileHeader{Name: "test/../../etc/file1.txt"}
+	f1, _ := w.CreateHeader(fh1)
+	f1.Write([]byte("File 1"))
+
+	w.Close()
+
+	_, err := processArchive(&Archive{Format: Zip}, buf.Bytes(), nil)
+	assertEqual(t, err, &ArchiveError{"test/../../etc/file1.txt", ErrUnsafePath})
+
+	mapper := func(s string) string {
+		return "etc/file1.txt"
+	}
+
+	files, err := processArchive(&Archive{Format: Zip, PathMapper: mapper}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 1)
+	assertEqual(t, files[0].path, "etc/file1.txt")
+}
+
+func TestArchiveTarGzUnsafePath(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	w := tar.NewWriter(zw)
+
+	mt1 := time.Unix(1300000000, 0)
+	fh1 := &tar.Header{Name: "./test/./file1.txt", Size: int64(6), ModTime: mt1}
+	w.WriteHeader(fh1)
+	w.Write([]byte("File 1"))
+
+	mt2 := time.Unix(1400000000, 0)
+	fh2 := &tar.Header{Name: "/etc/file2.txt", Size: int64(6), ModTime: mt2}
+	w.WriteHeader(fh2)
+	w.Write([]byte("File 2"))
+
+	w.Close()
+	zw.Close()
+
+	_, err := processArchive(&Archive{Format: TarGz}, buf.Bytes(), nil)
+	assertEqual(t, err, &ArchiveError{"/etc/file2.txt", ErrUnsafePath})
+
+	files, err := processArchive(&Archive{Format: TarGz, PathMapper: ReMap("^test/(.*)$", "${1}")}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 1)
+	assertEqual(t, files[0], &file{"file1.txt", []byte("File 1"), mt1, 0})
+
+	_, err = processArchive(&Archive{Format: TarGz, PathMapper: ReMap("^(.*)$", "../${1}")}, buf.Bytes(), nil)
+	assertEqual(t, err, &ArchiveError{"test/file1.txt", ErrUnsafePath})
+}
+
+func TestArchiveTarGzStripComponents(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	w := tar.NewWriter(zw)
+
+	mt1 := time.Unix(1300000000, 0)
+	fh1 := &tar.Header{Name: "project-1.2.3/file1.txt", Size: int64(6), ModTime: mt1}
+	w.WriteHeader(fh1)
+	w.Write([]byte("File 1"))
+
+	mt2 := time.Unix(1400000000, 0)
+	fh2 := &tar.Header{Name: "project-1.2.3/test/file2.txt", Size: int64(6), ModTime: mt2}
+	w.WriteHeader(fh2)
+	w.Write([]byte("File 2"))
+
+	w.Close()
+	zw.Close()
+
+	files, err := processArchive(&Archive{Format: TarGz, StripComponents: 1}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 2)
+	assertEqual(t, files[0], &file{"file1.txt", []byte("File 1"), mt1, 0})
+	assertEqual(t, files[1], &file{"test/file2.txt", []byte("File 2"), mt2, 0})
+
+	files, err = processArchive(&Archive{Format: TarGz, StripComponents: 2}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 1)
+	assertEqual(t, files[0], &file{"file2.txt", []byte("File 2"), mt2, 0})
+
+	files, err = processArchive(&Archive{Format: TarGz, StripComponents: AutoStrip,
+		PathMapper: ReMap("^test/(.*)$", "${1}")}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 1)
+	assertEqual(t, files[0], &file{"file2.txt", []byte("File 2"), mt2, 0})
+}
+
+func TestArchiveZipAutoStrip(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+
+	f1, _ := w.Create("test/file1.txt")
+	f1.Write([]byte("File 1"))
+
+	f2, _ := w.Create("file2.txt")
+	f2.Write([]byte("File 2"))
+
+	w.Close()
+
+	files, err := processArchive(&Archive{Format: Zip, StripComponents: AutoStrip}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 2)
+	assertEqual(t, files[0].path, "test/file1.txt")
+	assertEqual(t, files[1].path, "file2.txt")
+}
+
+func TestArchiveTarGzLinks(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	w := tar.NewWriter(zw)
+
+	mt1 := time.Unix(1300000000, 0)
+	fh1 := &tar.Header{Name: "test/v2.js", Size: int64(6), ModTime: mt1}
+	w.WriteHeader(fh1)
+	w.Write([]byte("File 1"))
+
+	mt2 := time.Unix(1400000000, 0)
+	lh1 := &tar.Header{Name: "test/latest.js", Typeflag: tar.TypeSymlink, Linkname: "v2.js", ModTime: mt2}
+	w.WriteHeader(lh1)
+
+	mt3 := time.Unix(1500000000, 0)
+	lh2 := &tar.Header{Name: "test/current.js", Typeflag: tar.TypeLink, Linkname: "test/latest.js", ModTime: mt3}
+	w.WriteHeader(lh2)
+
+	w.Close()
+	zw.Close()
+
+	files, err := processArchive(&Archive{Format: TarGz}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 1)
+
+	files, err = processArchive(&Archive{Format: TarGz, ResolveLinks: true}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 3)
+	assertEqual(t, files[0], &file{"test/v2.js", []byte("File 1"), mt1, 0})
+	assertEqual(t, files[1], &file{"test/latest.js", []byte("File 1"), mt2, 0})
+	assertEqual(t, files[2], &file{"test/current.js", []byte("File 1"), mt3, 0})
+}
+
+func TestArchiveTarGzDirLinks(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	w := tar.NewWriter(zw)
+
+	mt1 := time.Unix(1300000000, 0)
+	w.WriteHeader(&tar.Header{Name: "dist/v2/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: mt1})
+	w.WriteHeader(&tar.Header{Name: "dist/v2/app.js", Size: int64(6), ModTime: mt1})
+	w.Write([]byte("File 1"))
+	w.WriteHeader(&tar.Header{Name: "dist/v2/lib/util.js", Size: int64(6), ModTime: mt1})
+	w.Write([]byte("File 2"))
+	w.WriteHeader(&tar.Header{Name: "dist/v2/main.js", Typeflag: tar.TypeSymlink, Linkname: "app.js", ModTime: mt1})
+
+	mt2 := time.Unix(1400000000, 0)
+	w.WriteHeader(&tar.Header{Name: "dist/latest", Typeflag: tar.TypeSymlink, Linkname: "v2", ModTime: mt2})
+	w.WriteHeader(&tar.Header{Name: "dist/lib", Typeflag: tar.TypeSymlink, Linkname: "v2/lib", ModTime: mt2})
+
+	w.Close()
+	zw.Close()
+
+	files, err := processArchive(&Archive{Format: TarGz, ResolveLinks: true}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 7)
+	assertEqual(t, files[3], &file{"dist/latest/app.js", []byte("File 1"), mt1, 0})
+	assertEqual(t, files[4], &file{"dist/latest/lib/util.js", []byte("File 2"), mt1, 0})
+	assertEqual(t, files[5], &file{"dist/latest/main.js", []byte("File 1"), mt1, 0})
+	assertEqual(t, files[6], &file{"dist/lib/util.js", []byte("File 2"), mt1, 0})
+
+	files, err = processArchive(&Archive{Format: TarGz, ResolveLinks: true}, buf.Bytes(), &Opts{KeepDirs: true})
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 10)
+	assertEqual(t, files[4], &file{"dist/latest", nil, mt2, os.ModeDir | 0755})
+
+	// Links to their parent directories are loops
+	buf = new(bytes.Buffer)
+	zw = gzip.NewWriter(buf)
+	w = tar.NewWriter(zw)
+
+	w.WriteHeader(&tar.Header{Name: "dist/app.js", Size: int64(6), ModTime: mt1})
+	w.Write([]byte("File 1"))
+	w.WriteHeader(&tar.Header{Name: "dist/loop", Typeflag: tar.TypeSymlink, Linkname: ".", ModTime: mt2})
+
+	w.Close()
+	zw.Close()
+
+	_, err = processArchive(&Archive{Format: TarGz, ResolveLinks: true}, buf.Bytes(), nil)
+	assertEqual(t, err, &ArchiveError{"dist/loop", ErrLinkLoop})
+}
+
+func TestArchiveTarGzLinksInvalid(t *testing.T) {
+	links := []struct {
+		headers []*tar.Header
+		err     error
+	}{
+		{[]*tar.Header{
+			{Name: "test/link", Typeflag: tar.TypeSymlink, Linkname: "../../etc/passwd"},
+		}, &ArchiveError{"test/link", ErrUnsafePath}},
+		{[]*tar.Header{
+			{Name: "test/link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+		}, &ArchiveError{"test/link", ErrUnsafePath}},
+		{[]*tar.Header{
+			{Name: "test/link", Typeflag: tar.TypeSymlink, Linkname: "missing"},
+		}, &ArchiveError{"test/link", ErrLinkTarget}},
+		{[]*tar.Header{
+			{Name: "test/link1", Typeflag: tar.TypeSymlink, Linkname: "link2"},
+			{Name: "test/link2", Typeflag: tar.TypeLink, Linkname: "test/link1"},
+		}, &ArchiveError{"test/link1", ErrLinkLoop}},
+	}
+
+	keepA := func(fp string) string {
+		if fp == "test/a.txt" {
+			return fp
+		}
+		return ""
+	}
+
+	for _, l := range links {
+		buf := new(bytes.Buffer)
+		zw := gzip.NewWriter(buf)
+		w := tar.NewWriter(zw)
+
+		w.WriteHeader(&tar.Header{Name: "test/a.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 1})
+		w.Write([]byte("A"))
+
+		for _, h := range l.headers {
+			w.WriteHeader(h)
+		}
+
+		w.Close()
+		zw.Close()
+
+		_, err := processArchive(&Archive{Format: TarGz, ResolveLinks: true}, buf.Bytes(), nil)
+		assertEqual(t, err, l.err)
+
+		// Links dropped by the PathMapper are not resolved
+		files, err := processArchive(&Archive{Format: TarGz, ResolveLinks: true, PathMapper: keepA}, buf.Bytes(), nil)
+		assertEqual(t, err, nil)
+		assertEqual(t, len(files), 1)
+		assertEqual(t, files[0].path, "test/a.txt")
+		assertEqual(t, files[0].data, []byte("A"))
+	}
+}
+
+func TestArchiveZipLinks(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+
+	f1, _ := w.Create("test/v2.js")
+	f1.Write([]byte("File 1"))
+
+	lh := &zip.FileHeader{Name: "test/latest.js"}
+	lh.SetMode(0777 | os.ModeSymlink)
+	l, _ := w.CreateHeader(lh)
+	l.Write([]byte("v2.js"))
+
+	w.Close()
+
+	files, err := processArchive(&Archive{Format: Zip}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 1)
+
+	files, err = processArchive(&Archive{Format: Zip, ResolveLinks: true}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 2)
+	assertEqual(t, files[1].path, "test/latest.js")
+	assertEqual(t, files[1].data, []byte("File 1"))
+}
+
+func TestArchiveNested(t *testing.T) {
+	tbuf := new(bytes.Buffer)
+	zw := gzip.NewWriter(tbuf)
+	tw := tar.NewWriter(zw)
+
+	mt1 := time.Unix(1300000000, 0)
+	fh1 := &tar.Header{Name: "dist-1.0/file1.txt", Size: int64(6), ModTime: mt1}
+	tw.WriteHeader(fh1)
+	tw.Write([]byte("File 1"))
+
+	mt2 := time.Unix(1400000000, 0)
+	fh2 := &tar.Header{Name: "dist-1.0/file2.txt", Size: int64(6), ModTime: mt2}
+	tw.WriteHeader(fh2)
+	tw.Write([]byte("File 2"))
+
+	tw.Close()
+	zw.Close()
+
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+
+	f1, _ := w.Create("release/dist.tar.gz")
+	f1.Write(tbuf.Bytes())
+
+	f2, _ := w.Create("release/README")
+	f2.Write([]byte("Readme"))
+
+	w.Close()
+
+	checksum := hex.EncodeToString(calcSHA256(tbuf.Bytes()))
+
+	arch := &Archive{
+		Format:          Zip,
+		StripComponents: 1,
+		Nested: &Nested{
+			Member:   "dist.tar.gz",
+			Checksum: &Checksum{SHA256, checksum},
+			Archive: &Archive{
+				Format:     TarGz,
+				PathMapper: ReMap("^dist-1.0/(file1.txt)$", "${1}"),
+			},
+		},
+	}
+
+	files, err := processArchive(arch, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 1)
+	assertEqual(t, files[0], &file{"file1.txt", []byte("File 1"), mt1, 0})
+
+	arch.Nested.Checksum = &Checksum{SHA256, "1234"}
+	_, err = processArchive(arch, buf.Bytes(), nil)
+	assertEqual(t, err, &ChecksumError{"dist.tar.gz", ErrChecksumMismatch})
+
+	arch.Nested.Checksum = nil
+	arch.Nested.Member = "README"
+	_, err = processArchive(arch, buf.Bytes(), nil)
+	assertEqual(t, err, &ArchiveError{"README", io.ErrUnexpectedEOF})
+
+	arch.Nested.Member = "missing.tar.gz"
+	_, err = processArchive(arch, buf.Bytes(), nil)
+	assertEqual(t, err, &ArchiveError{"missing.tar.gz", ErrMemberNotFound})
+}
+
+func TestArchiveTarGzEntryMapper(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	w := tar.NewWriter(zw)
+
+	mt1 := time.Unix(1300000000, 0)
+	fh1 := &tar.Header{Name: "test/run.sh", Size: int64(6), Mode: 0755, ModTime: mt1}
+	w.WriteHeader(fh1)
+	w.Write([]byte("File 1"))
+
+	mt2 := time.Unix(1400000000, 0)
+	fh2 := &tar.Header{Name: "test/large.sh", Size: int64(10), Mode: 0755, ModTime: mt2}
+	w.WriteHeader(fh2)
+	w.Write([]byte("File 2 ..."))
+
+	mt3 := time.Unix(1500000000, 0)
+	fh3 := &tar.Header{Name: "test/file3.txt", Size: int64(6), Mode: 0644, ModTime: mt3}
+	w.WriteHeader(fh3)
+	w.Write([]byte("File 3"))
+
+	w.Close()
+	zw.Close()
+
+	var entries []Entry
+	mapper := func(e Entry) string {
+		entries = append(entries, e)
+		if e.Mode&0111 == 0 || e.Size > 6 {
+			return ""
+		}
+		return "bin/" + e.Path
+	}
+
+	files, err := processArchive(&Archive{Format: TarGz, StripComponents: 1,
+		EntryMapper: mapper}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 1)
+	assertEqual(t, files[0], &file{"bin/run.sh", []byte("File 1"), mt1, 0755})
+
+	assertEqual(t, len(entries), 3)
+	assertEqual(t, entries[0], Entry{"run.sh", 6, 0755, mt1})
+	assertEqual(t, entries[1], Entry{"large.sh", 10, 0755, mt2})
+	assertEqual(t, entries[2], Entry{"file3.txt", 6, 0644, mt3})
+}
+
+func TestArchiveTarGzKeepDirs(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	w := tar.NewWriter(zw)
+
+	mt1 := time.Unix(1300000000, 0)
+	dh1 := &tar.Header{Name: "project/", Typeflag: tar.TypeDir, Mode: 0755, ModTime: mt1}
+	w.WriteHeader(dh1)
+
+	mt2 := time.Unix(1400000000, 0)
+	fh2 := &tar.Header{Name: "project/file1.txt", Size: int64(6), ModTime: mt2}
+	w.WriteHeader(fh2)
+	w.Write([]byte("File 1"))
+
+	mt3 := time.Unix(1500000000, 0)
+	dh3 := &tar.Header{Name: "project/empty/", Typeflag: tar.TypeDir, Mode: 0700, ModTime: mt3}
+	w.WriteHeader(dh3)
+
+	w.Close()
+	zw.Close()
+
+	files, err := processArchive(&Archive{Format: TarGz, StripComponents: AutoStrip}, buf.Bytes(),
+		&Opts{KeepDirs: true})
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 2)
+	assertEqual(t, files[0], &file{"file1.txt", []byte("File 1"), mt2, 0})
+	assertEqual(t, files[1], &file{"empty", nil, mt3, os.ModeDir | 0700})
+
+	files, err = processArchive(&Archive{Format: TarGz, StripComponents: AutoStrip}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 1)
+}
+
+func TestArchiveTarGzKeepDirsRoot(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	w := tar.NewWriter(zw)
+
+	// As created by "tar -C dir -czf archive.tar.gz ."
+	mt1 := time.Unix(1300000000, 0)
+	w.WriteHeader(&tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0755, ModTime: mt1})
+	w.WriteHeader(&tar.Header{Name: "./file1.txt", Size: int64(6), ModTime: mt1})
+	w.Write([]byte("File 1"))
+	w.WriteHeader(&tar.Header{Name: "./empty/", Typeflag: tar.TypeDir, Mode: 0700, ModTime: mt1})
+
+	w.Close()
+	zw.Close()
+
+	files, err := processArchive(&Archive{Format: TarGz}, buf.Bytes(), &Opts{KeepDirs: true})
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 2)
+	assertEqual(t, files[0], &file{"file1.txt", []byte("File 1"), mt1, 0})
+	assertEqual(t, files[1], &file{"empty", nil, mt1, os.ModeDir | 0700})
+}
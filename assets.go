@@ -4,23 +4,31 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"strings"
 	"time"
-
-	mfs "github.com/ZoltanLajosKis/go-mapfs"
-	"github.com/shurcooL/vfsgen"
-	"golang.org/x/tools/godoc/vfs/httpfs"
 )
 
 // Source describes an asset source to be retrieved and processed.
 type Source struct {
-	Path     string
-	Location string
-	Checksum *Checksum
-	Archive  *Archive
+	Path       string
+	Location   string
+	Checksum   *Checksum
+	Decompress *Decompress
+	Archive    *Archive
+
+	// EntryMapper is executed on all files matched by a glob pattern in
+	// Location, and receives their paths relative to Path. If "" is
+	// returned, the file is dropped.
+	EntryMapper EntryMapper
+
+	// ModTime overrides the modification time of all files created from
+	// the asset source, regardless of Opts.ModTime.
+	ModTime *time.Time
 }
 
-// Opts provides optional parameters to the Compile function.
+// Opts provides optional parameters to the RetrieveWithOpts, RetrieveFS,
+// Compile and Export functions.
 type Opts struct {
 	// BuildTags are the build tags in the generated source code.
 	// Defaults to no tags.
@@ -29,35 +37,143 @@ type Opts struct {
 	// VariableComment is the comment of the variable in the generated source code.
 	// Defaults to "<VariableName> implements a http.FileSystem.".
 	VariableComment string
+
+	// Limits restricts the resources used while retrieving and extracting
+	// asset sources.
+	// Defaults to no limits.
+	Limits *Limits
+
+	// Conflict specifies how assets with the same path are handled.
+	// Defaults to FailOnConflict.
+	Conflict ConflictPolicy
+
+	// KeepDirs keeps the directories found in archives and matched by glob
+	// patterns, so empty directories appear in the file system too.
+	// Defaults to dropping directories.
+	KeepDirs bool
+
+	// ModTime specifies how the modification times of the assets are set.
+	// Defaults to KeepModTime.
+	ModTime ModTimePolicy
+
+	// FixedTime is the modification time of the assets with FixedModTime.
+	// Defaults to the time in the SOURCE_DATE_EPOCH environment variable.
+	FixedTime time.Time
+
+	// EmbedDir makes Compile write the assets to this directory, relative to
+	// the directory of the source file, and generate an embed.FS variable
+	// with a go:embed directive instead of using vfsgen. A non-empty directory
+	// is only replaced if it was written by Compile.
+	// Defaults to using vfsgen.
+	EmbedDir string
+
+	// Prune makes Export remove the files in the directory that are not
+	// assets, such as the ones left over from previous exports.
+	// Defaults to keeping them.
+	Prune bool
+
+	// DevFilePath makes Compile generate a second source file at this
+	// location, declaring the same variable with the negated BuildTags, which
+	// serves the files of DevDir from the local file system. It requires
+	// BuildTags and DevDir, and cannot be used with EmbedDir.
+	// Defaults to no development source file.
+	DevFilePath string
+
+	// DevDir is the directory served by the variable in DevFilePath.
+	// Defaults to no directory.
+	DevDir string
+
+	// ManifestPath makes Compile write a JSON manifest of the assets to this
+	// location (see Manifest).
+	// Defaults to no manifest.
+	ManifestPath string
+
+	// HashNames selects the asset files to be renamed to include the first 8
+	// hexadecimal digits of the SHA-256 hash of their contents, for example
+	// "app.css" to "app.3f9a1c2d.css". Compile also generates the
+	// <VariableName>Paths map and the <VariableName>Path function to look up
+	// the renamed paths.
+	// Defaults to keeping the paths.
+	HashNames PathFilter
+
+	// Precompress selects the asset files to be stored with brotli and gzip
+	// compressed variants too, at their paths with ".br" and ".gz" suffixes.
+	// Variants that are not smaller than the file are dropped. The files of
+	// Retrieve, RetrieveWithOpts and RetrieveFS implement EncodedFile to
+	// access the variants.
+	// Defaults to no variants.
+	Precompress PathFilter
 }
 
 type file struct {
 	path    string
 	data    []byte
 	modTime time.Time
+	mode    os.FileMode
 }
 
 // Retrieve retrieves and processes the specified asset sources, and returns
 // them using a http.FileSystem interface.
 func Retrieve(sources []*Source) (http.FileSystem, error) {
-	files := make(mfs.Files)
+	return RetrieveWithOpts(sources, nil)
+}
+
+// RetrieveWithOpts is like Retrieve, but accepts optional parameters.
+func RetrieveWithOpts(sources []*Source, opts *Opts) (http.FileSystem, error) {
+	if opts == nil {
+		opts = &Opts{}
+	}
+
+	assets, err := retrieveAssets(sources, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return newMemFS(assets.files, assets.variants), nil
+}
+
+func retrieveAssets(sources []*Source, opts *Opts) (*assetFiles, error) {
+	assets := newAssetFiles(opts.Conflict)
+
+	fixed, err := fixedModTime(opts)
+	if err != nil {
+		return nil, err
+	}
 
 	for i, source := range sources {
 		log.Printf("Processing asset source (%d/%d): %s ...", i+1, len(sources), source.Location)
 
+		// Store a file with the requested modification time
+		add := func(file *file) error {
+			if source.ModTime != nil {
+				file.modTime = *source.ModTime
+			} else if fixed != nil {
+				file.modTime = *fixed
+			}
+			return assets.add(file, source.Location)
+		}
+
 		// Retrieve the file or files
-		retFiles, err := retrieve(source.Location)
+		retFiles, err := retrieve(source.Location, opts)
 		if err != nil {
 			return nil, &RetrieveError{source.Location, err}
 		}
 
 		// If multiple files are returned store them and finish processing.
-		// Chekcsum and archive not supported for multiple files.
-		if len(retFiles) > 1 {
+		// Chekcsum and archive not supported for multiple files. A single
+		// glob match is stored the same way when it needs to be mapped.
+		if len(retFiles) > 1 || (source.EntryMapper != nil && isGlob(source.Location)) {
 			for _, file := range retFiles {
-				path := strings.TrimSuffix(source.Path, "/") + "/" + file.path
-				log.Printf("Created asset: %s ...", path)
-				files[path] = &mfs.File{file.data, file.modTime}
+				if source.EntryMapper != nil {
+					file.path = source.EntryMapper(Entry{file.path, int64(len(file.data)), file.mode, file.modTime})
+					if file.path == "" {
+						continue
+					}
+				}
+				file.path = mountPath(source.Path, file.path)
+				if err := add(file); err != nil {
+					return nil, err
+				}
 			}
 			continue
 		}
@@ -73,63 +189,128 @@ func Retrieve(sources []*Source) (http.FileSystem, error) {
 			}
 		}
 
+		// Decompress the file if requested
+		if source.Decompress != nil {
+			file.data, err = decompress(source.Decompress, file.data, opts.Limits)
+			if err != nil {
+				return nil, &DecompressError{source.Location, err}
+			}
+		}
+
 		// If the file is not an archive store it and finish processing.
 		if source.Archive == nil {
-			log.Printf("Created asset: %s ...", source.Path)
-			files[source.Path] = &mfs.File{file.data, file.modTime}
+			file.path = source.Path
+			if err := add(file); err != nil {
+				return nil, err
+			}
 			continue
 		}
 
 		// Extract files from the archive and store them.
-		archFiles, err := processArchive(source.Archive, file.data)
+		archFiles, err := processArchive(source.Archive, file.data, opts)
 		if err != nil {
 			return nil, &ArchiveError{source.Location, err}
 		}
 
 		for _, file := range archFiles {
-			log.Printf("Created asset: %s ...", file.path)
-			files[file.path] = &mfs.File{file.data, file.modTime}
+			if source.Archive.Mount {
+				file.path = mountPath(source.Path, file.path)
+			}
+			if err := add(file); err != nil {
+				return nil, err
+			}
 		}
 
 	}
 
-	fs, err := mfs.New(files)
-	if err != nil {
-		return nil, err
+	if opts.HashNames != nil {
+		if err := assets.hashNames(opts.HashNames); err != nil {
+			return nil, err
+		}
 	}
 
-	return httpfs.New(fs), nil
+	if opts.Precompress != nil {
+		if err := assets.precompress(opts.Precompress); err != nil {
+			return nil, err
+		}
+	}
+
+	return assets, nil
 }
 
 // Compile retrieves and processes the specified asset sources, and
 // compiles them to the specified variable in the source file.
+//
+// A fingerprint of the asset sources, the options and the asset contents is
+// stored in the header of the source file. If the source file already has
+// the same fingerprint, it is not written again.
 func Compile(sources []*Source, filePath string, pkgName string, varName string, opts *Opts) error {
+	if opts == nil {
+		opts = &Opts{}
+	}
 
-	fs, err := Retrieve(sources)
-	if err != nil {
+	if err := checkDevOpts(opts); err != nil {
 		return err
 	}
 
-	if opts == nil {
-		opts = &Opts{}
+	assets, err := retrieveAssets(sources, opts)
+	if err != nil {
+		return err
 	}
 
-	if opts.VariableComment == "" {
+	if opts.VariableComment == "" && opts.EmbedDir != "" {
+		opts.VariableComment = fmt.Sprintf("%s implements a fs.FS.", varName)
+	} else if opts.VariableComment == "" {
 		opts.VariableComment = fmt.Sprintf("%s implements a http.FileSystem.", varName)
 	}
 
-	err = vfsgen.Generate(fs, vfsgen.Options{
-		Filename:        filePath,
-		PackageName:     pkgName,
-		BuildTags:       opts.BuildTags,
-		VariableName:    varName,
-		VariableComment: opts.VariableComment,
-	})
+	fp := fingerprint(sources, pkgName, varName, opts, assets)
+	if readFingerprint(filePath) == fp && outputsExist(filePath, opts) {
+		log.Printf("Assets unchanged: %s ...", filePath)
+		return nil
+	}
+
+	if opts.EmbedDir != "" {
+		err = compileEmbed(assets, filePath, pkgName, varName, opts)
+	} else {
+		err = compileVFS(assets, filePath, pkgName, varName, opts)
+	}
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if opts.HashNames != nil {
+		err = appendHashedPaths(filePath, varName, assets.hashed)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.DevFilePath != "" {
+		err = compileDev(pkgName, varName, opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.ManifestPath != "" {
+		err = writeManifest(opts.ManifestPath, assets)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeFingerprint(filePath, fp)
+}
+
+// mountPath returns the path of a file placed under the specified directory.
+func mountPath(dir string, path string) string {
+	dir = strings.TrimSuffix(dir, "/")
+	if dir == "" {
+		return path
+	}
+
+	return dir + "/" + path
 }
 
 // RetrieveError is returned when there is a problem retrieving an asset source
@@ -152,6 +333,16 @@ func (e *ChecksumError) Error() string {
 	return e.Location + ": " + e.Err.Error()
 }
 
+// DecompressError is returned when there is a problem decompressing an asset source
+type DecompressError struct {
+	Location string
+	Err      error
+}
+
+func (e *DecompressError) Error() string {
+	return e.Location + ": " + e.Err.Error()
+}
+
 // ArchiveError is returned when there is a problem processing the archive
 type ArchiveError struct {
 	Path string
@@ -1,8 +1,10 @@
 package assets
 
 import (
+	"archive/tar"
 	"archive/zip"
 	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -33,10 +35,10 @@ func TestCompile(t *testing.T) {
 	defer svr.Close()
 
 	sources := []*Source{
-		{"assets.txt",
-			strings.Join([]string{svr.URL, "/assets.txt"}, ""), nil, nil},
-		{"retrieve_test.go",
-			"retrieve_test.go", nil, nil},
+		{Path: "assets.txt",
+			Location: strings.Join([]string{svr.URL, "/assets.txt"}, "")},
+		{Path: "retrieve_test.go",
+			Location: "retrieve_test.go"},
 	}
 
 	err = Compile(sources, filepath.Join(dir, "assets.go"), "assets", "fs", nil)
@@ -81,8 +83,8 @@ func TestRetrieveGlob(t *testing.T) {
 	}
 
 	sources := []*Source{
-		{"newdir",
-			dir + "/test/t[12]/file[123].txt", nil, nil},
+		{Path: "newdir",
+			Location: dir + "/test/t[12]/file[123].txt"},
 	}
 
 	fs, err := Retrieve(sources)
@@ -110,6 +112,160 @@ func TestRetrieveGlob(t *testing.T) {
 	assertNotEqual(t, err, nil)
 }
 
+func TestRetrieveGlobKeepDirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = os.MkdirAll(dir+"/test/empty", 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(dir+"/test/file1.txt", []byte("File 1"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []*Source{
+		{Path: "newdir",
+			Location: dir + "/test/*"},
+	}
+
+	_, err = Retrieve(sources)
+	assertNotEqual(t, err, nil)
+
+	fs, err := RetrieveWithOpts(sources, &Opts{KeepDirs: true})
+	assertEqual(t, err, nil)
+
+	newdir, err := fs.Open("newdir")
+	assertEqual(t, err, nil)
+	infos, err := newdir.Readdir(0)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(infos), 2)
+	assertEqual(t, infos[0].Name(), "empty")
+	assertEqual(t, infos[0].IsDir(), true)
+	assertEqual(t, infos[1].Name(), "file1.txt")
+}
+
+func TestRetrieveGlobEntryMapper(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(dir+"/file1.txt", []byte("File 1"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(dir+"/file2.txt", []byte("File 2 ..."), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mapper := func(e Entry) string {
+		if e.Size > 6 {
+			return ""
+		}
+		return "small/" + e.Path
+	}
+
+	sources := []*Source{
+		{Path: "newdir",
+			Location: dir + "/file*.txt", EntryMapper: mapper},
+	}
+
+	fs, err := Retrieve(sources)
+	assertEqual(t, err, nil)
+
+	file1, err := fs.Open("newdir/small/file1.txt")
+	assertEqual(t, err, nil)
+	fdata1, err := ioutil.ReadAll(file1)
+	assertEqual(t, err, nil)
+	assertEqual(t, fdata1, []byte("File 1"))
+
+	_, err = fs.Open("newdir/file2.txt")
+	assertNotEqual(t, err, nil)
+	_, err = fs.Open("newdir/small/file2.txt")
+	assertNotEqual(t, err, nil)
+}
+
+func TestRetrieveGlobEntryMapperSingle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(dir+"/file1.txt", []byte("File 1"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mapper := func(e Entry) string {
+		if e.Size > 6 {
+			return ""
+		}
+		return "small/" + e.Path
+	}
+
+	sources := []*Source{
+		{Path: "newdir",
+			Location: dir + "/file*.txt", EntryMapper: mapper},
+	}
+
+	fs, err := Retrieve(sources)
+	assertEqual(t, err, nil)
+
+	file1, err := fs.Open("newdir/small/file1.txt")
+	assertEqual(t, err, nil)
+	fdata1, err := ioutil.ReadAll(file1)
+	assertEqual(t, err, nil)
+	assertEqual(t, fdata1, []byte("File 1"))
+
+	err = ioutil.WriteFile(dir+"/file1.txt", []byte("File 1 ..."), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err = Retrieve(sources)
+	assertEqual(t, err, nil)
+
+	_, err = fs.Open("newdir")
+	assertNotEqual(t, err, nil)
+}
+
+func TestRetrieveModTime(t *testing.T) {
+	mt1 := time.Unix(1300000000, 0)
+	mt2 := time.Unix(1400000000, 0)
+
+	sources := []*Source{
+		{Path: "file1.go",
+			Location: "retrieve_test.go"},
+		{Path: "file2.go",
+			Location: "assets_test.go", ModTime: &mt2},
+	}
+
+	fs, err := RetrieveWithOpts(sources, &Opts{ModTime: FixedModTime, FixedTime: mt1})
+	assertEqual(t, err, nil)
+
+	file1, err := fs.Open("file1.go")
+	assertEqual(t, err, nil)
+	fstat1, err := file1.Stat()
+	assertEqual(t, err, nil)
+	assertEqual(t, fstat1.ModTime(), mt1)
+
+	file2, err := fs.Open("file2.go")
+	assertEqual(t, err, nil)
+	fstat2, err := file2.Stat()
+	assertEqual(t, err, nil)
+	assertEqual(t, fstat2.ModTime(), mt2)
+}
+
 func TestCompileArchive(t *testing.T) {
 	dir, err := ioutil.TempDir("", "go-assets")
 	if err != nil {
@@ -150,8 +306,8 @@ func TestCompileArchive(t *testing.T) {
 	}
 
 	sources := []*Source{
-		{"arch.zip",
-			path.Join(dir, "arch.zip"), nil, &Archive{Zip, nil}},
+		{Path: "arch.zip",
+			Location: path.Join(dir, "arch.zip"), Archive: &Archive{Format: Zip}},
 	}
 
 	fs, err := Retrieve(sources)
@@ -188,6 +344,100 @@ func TestCompileArchive(t *testing.T) {
 	assertNotEqual(t, err, nil)
 }
 
+func TestRetrieveArchiveMount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+
+	f1, _ := w.Create("test/file1.txt")
+	f1.Write([]byte("File 1"))
+
+	w.Close()
+
+	err = ioutil.WriteFile(path.Join(dir, "arch.zip"), buf.Bytes(), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []*Source{
+		{Path: "arch1",
+			Location: path.Join(dir, "arch.zip"), Archive: &Archive{Format: Zip, Mount: true}},
+		{Path: "arch2/",
+			Location: path.Join(dir, "arch.zip"), Archive: &Archive{Format: Zip, Mount: true}},
+	}
+
+	fs, err := Retrieve(sources)
+	assertEqual(t, err, nil)
+
+	file1, err := fs.Open("arch1/test/file1.txt")
+	assertEqual(t, err, nil)
+	fdata1, err := ioutil.ReadAll(file1)
+	assertEqual(t, err, nil)
+	assertEqual(t, fdata1, []byte("File 1"))
+
+	file2, err := fs.Open("arch2/test/file1.txt")
+	assertEqual(t, err, nil)
+	fdata2, err := ioutil.ReadAll(file2)
+	assertEqual(t, err, nil)
+	assertEqual(t, fdata2, []byte("File 1"))
+
+	_, err = fs.Open("test/file1.txt")
+	assertNotEqual(t, err, nil)
+}
+
+func TestRetrieveArchiveMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	buf := new(bytes.Buffer)
+	zw := gzip.NewWriter(buf)
+	w := tar.NewWriter(zw)
+
+	fh1 := &tar.Header{Name: "test/run.sh", Size: int64(6), Mode: 0755}
+	w.WriteHeader(fh1)
+	w.Write([]byte("File 1"))
+
+	fh2 := &tar.Header{Name: "test/file2.txt", Size: int64(6), Mode: 0644}
+	w.WriteHeader(fh2)
+	w.Write([]byte("File 2"))
+
+	w.Close()
+	zw.Close()
+
+	err = ioutil.WriteFile(path.Join(dir, "arch.tar.gz"), buf.Bytes(), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []*Source{
+		{Path: "arch.tar.gz",
+			Location: path.Join(dir, "arch.tar.gz"), Archive: &Archive{Format: TarGz}},
+	}
+
+	fs, err := Retrieve(sources)
+	assertEqual(t, err, nil)
+
+	file1, err := fs.Open("test/run.sh")
+	assertEqual(t, err, nil)
+	fstat1, err := file1.Stat()
+	assertEqual(t, err, nil)
+	assertEqual(t, fstat1.Mode(), os.FileMode(0755))
+
+	file2, err := fs.Open("test/file2.txt")
+	assertEqual(t, err, nil)
+	fstat2, err := file2.Stat()
+	assertEqual(t, err, nil)
+	assertEqual(t, fstat2.Mode(), os.FileMode(0644))
+}
+
 func TestCompileRetrieveError(t *testing.T) {
 	dir, err := ioutil.TempDir("", "go-assets")
 	if err != nil {
@@ -196,8 +446,8 @@ func TestCompileRetrieveError(t *testing.T) {
 	defer os.RemoveAll(dir)
 
 	sources := []*Source{
-		{"xxxx",
-			"xxxx", nil, nil},
+		{Path: "xxxx",
+			Location: "xxxx"},
 	}
 
 	err = Compile(sources, filepath.Join(dir, "assets.go"), "assets", "fs", nil)
@@ -213,8 +463,8 @@ func TestCompileChecksumError(t *testing.T) {
 	defer os.RemoveAll(dir)
 
 	sources := []*Source{
-		{"retrieve_test.go",
-			"retrieve_test.go", &Checksum{MD5, "1234"}, nil},
+		{Path: "retrieve_test.go",
+			Location: "retrieve_test.go", Checksum: &Checksum{MD5, "1234"}},
 	}
 
 	err = Compile(sources, filepath.Join(dir, "assets.go"), "assets", "fs", nil)
@@ -222,6 +472,45 @@ func TestCompileChecksumError(t *testing.T) {
 	assertEqual(t, err.Error(), "retrieve_test.go: checksum mismatch")
 }
 
+func TestCompileDecompressError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sources := []*Source{
+		{Path: "retrieve_test.go",
+			Location: "retrieve_test.go", Decompress: &Decompress{DetectCompression}},
+	}
+
+	err = Compile(sources, filepath.Join(dir, "assets.go"), "assets", "fs", nil)
+	assertEqual(t, err, &DecompressError{"retrieve_test.go", ErrCompressionUnknown})
+	assertEqual(t, err.Error(), "retrieve_test.go: unknown compression format")
+}
+
+func TestCompileConflictError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sources := []*Source{
+		{Path: "test.go",
+			Location: "retrieve_test.go"},
+		{Path: "test.go",
+			Location: "assets_test.go"},
+	}
+
+	err = Compile(sources, filepath.Join(dir, "assets.go"), "assets", "fs", nil)
+	assertEqual(t, err, &ConflictError{"test.go", "retrieve_test.go", "assets_test.go"})
+	assertEqual(t, err.Error(), "test.go: conflict between retrieve_test.go and assets_test.go")
+
+	err = Compile(sources, filepath.Join(dir, "assets.go"), "assets", "fs", &Opts{Conflict: LastWins})
+	assertEqual(t, err, nil)
+}
+
 func TestCompileArchiveError(t *testing.T) {
 	dir, err := ioutil.TempDir("", "go-assets")
 	if err != nil {
@@ -230,8 +519,8 @@ func TestCompileArchiveError(t *testing.T) {
 	defer os.RemoveAll(dir)
 
 	sources := []*Source{
-		{"retrieve_test.go",
-			"retrieve_test.go", nil, &Archive{Zip, nil}},
+		{Path: "retrieve_test.go",
+			Location: "retrieve_test.go", Archive: &Archive{Format: Zip}},
 	}
 
 	err = Compile(sources, filepath.Join(dir, "assets.go"), "assets", "fs", nil)
@@ -0,0 +1,151 @@
+package assets
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// bundleTime is the modification time of bundled files without one.
+var bundleTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Bundle retrieves and processes the specified asset sources, and writes
+// them to w as an archive of the specified format. The files are written in
+// path order, so the same assets always result in the same archive.
+func Bundle(sources []*Source, w io.Writer, format ArchiveFormat, opts *Opts) error {
+	if opts == nil {
+		opts = &Opts{}
+	}
+
+	if format != Zip && format != TarGz && format != TarZst {
+		return ErrArchiveUnknown
+	}
+
+	assets, err := retrieveAssets(sources, opts)
+	if err != nil {
+		return err
+	}
+
+	return bundleFiles(w, format, assets.files)
+}
+
+func bundleFiles(w io.Writer, format ArchiveFormat, files map[string]*file) error {
+	switch format {
+	case Zip:
+		return bundleZip(w, files)
+	case TarGz:
+		zw := gzip.NewWriter(w)
+		if err := bundleTar(zw, files); err != nil {
+			return err
+		}
+		return zw.Close()
+	case TarZst:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return err
+		}
+		if err := bundleTar(zw, files); err != nil {
+			zw.Close()
+			return err
+		}
+		return zw.Close()
+	default:
+		return ErrArchiveUnknown
+	}
+}
+
+func bundleZip(w io.Writer, files map[string]*file) error {
+	zw := zip.NewWriter(w)
+
+	for _, f := range sortedFiles(files) {
+		hdr := &zip.FileHeader{
+			Name:     f.path,
+			Method:   zip.Deflate,
+			Modified: bundleModTime(f),
+		}
+		hdr.SetMode(bundleMode(f))
+		if f.mode.IsDir() {
+			hdr.Name += "/"
+			hdr.Method = zip.Store
+		}
+
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func bundleTar(w io.Writer, files map[string]*file) error {
+	tw := tar.NewWriter(w)
+
+	for _, f := range sortedFiles(files) {
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     f.path,
+			Size:     int64(len(f.data)),
+			Mode:     int64(bundleMode(f).Perm()),
+			ModTime:  bundleModTime(f),
+		}
+		if f.mode.IsDir() {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Name += "/"
+			hdr.Size = 0
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+// sortedFiles returns the files in path order.
+func sortedFiles(files map[string]*file) []*file {
+	sorted := []*file{}
+	for _, f := range files {
+		sorted = append(sorted, f)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].path < sorted[j].path
+	})
+
+	return sorted
+}
+
+// bundleModTime returns the modification time of a bundled file.
+func bundleModTime(f *file) time.Time {
+	if f.modTime.IsZero() {
+		return bundleTime
+	}
+
+	return f.modTime.UTC()
+}
+
+// bundleMode returns the mode of a bundled file.
+func bundleMode(f *file) os.FileMode {
+	if f.mode.IsDir() {
+		if f.mode.Perm() == 0 {
+			return os.ModeDir | 0755
+		}
+		return os.ModeDir | f.mode.Perm()
+	}
+
+	return filePerm(f.mode)
+}
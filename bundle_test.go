@@ -0,0 +1,83 @@
+package assets
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBundle(t *testing.T) {
+	mt := time.Unix(1300000000, 0)
+
+	files := map[string]*file{
+		"test/file1.txt": {"test/file1.txt", []byte("File 1"), mt, 0644},
+		"test/run.sh":    {"test/run.sh", []byte("File 2"), mt, 0755},
+		"test/empty":     {"test/empty", nil, mt, os.ModeDir | 0755},
+		"file3.txt":      {"file3.txt", []byte("File 3"), time.Time{}, 0},
+	}
+
+	for _, format := range []ArchiveFormat{Zip, TarGz, TarZst} {
+		var b bytes.Buffer
+		err := bundleFiles(&b, format, files)
+		assertEqual(t, err, nil)
+
+		entries, err := processArchive(&Archive{Format: format}, b.Bytes(), &Opts{KeepDirs: true})
+		assertEqual(t, err, nil)
+		assertEqual(t, len(entries), 4)
+
+		assertEqual(t, entries[0].path, "file3.txt")
+		assertEqual(t, entries[0].data, []byte("File 3"))
+		assertEqual(t, entries[0].modTime.Equal(bundleTime), true)
+		assertEqual(t, entries[0].mode, os.FileMode(0644))
+
+		assertEqual(t, entries[1].path, "test/empty")
+		assertEqual(t, entries[1].mode.IsDir(), true)
+
+		assertEqual(t, entries[2].path, "test/file1.txt")
+		assertEqual(t, entries[2].data, []byte("File 1"))
+		assertEqual(t, entries[2].modTime.Equal(mt), true)
+
+		assertEqual(t, entries[3].path, "test/run.sh")
+		assertEqual(t, entries[3].mode, os.FileMode(0755))
+
+		// The same files always result in the same archive
+		var b2 bytes.Buffer
+		err = bundleFiles(&b2, format, files)
+		assertEqual(t, err, nil)
+		assertEqual(t, b2.Bytes(), b.Bytes())
+	}
+}
+
+func TestBundleSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "assets.txt"), []byte("Assets."), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []*Source{{Path: "static/assets.txt", Location: filepath.Join(dir, "assets.txt")}}
+
+	var b bytes.Buffer
+	err = Bundle(sources, &b, TarGz, nil)
+	assertEqual(t, err, nil)
+
+	entries, err := processArchive(&Archive{Format: TarGz}, b.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(entries), 1)
+	assertEqual(t, entries[0].path, "static/assets.txt")
+	assertEqual(t, entries[0].data, []byte("Assets."))
+
+	err = Bundle(sources, &b, 10, nil)
+	assertEqual(t, err, ErrArchiveUnknown)
+
+	err = Bundle([]*Source{{Path: "missing.txt", Location: filepath.Join(dir, "missing.txt")}}, &b, Zip, nil)
+	assertNotEqual(t, err, nil)
+}
@@ -0,0 +1,110 @@
+package assets
+
+import (
+	"log"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ConflictPolicy enumerates the ways of handling assets with the same path.
+type ConflictPolicy int
+
+const (
+	// FailOnConflict returns a ConflictError when two assets have the same path.
+	FailOnConflict = iota
+	// FirstWins keeps the asset that was created first.
+	FirstWins
+	// LastWins keeps the asset that was created last.
+	LastWins
+	// RenameDuplicates keeps both assets, and stores the later one at a new
+	// path with a numeric suffix, for example "file-1.txt".
+	RenameDuplicates
+)
+
+// assetFiles collects the asset files and their originating asset source
+// locations, and resolves path conflicts. It also records the parent
+// directories of the assets with the origin of the first asset in them, the
+// paths renamed to include content hashes, and the paths of the precompressed
+// variants by content coding.
+type assetFiles struct {
+	policy   ConflictPolicy
+	files    map[string]*file
+	origins  map[string]string
+	dirs     map[string]string
+	hashed   map[string]string
+	variants map[string]map[string]string
+}
+
+func newAssetFiles(policy ConflictPolicy) *assetFiles {
+	return &assetFiles{policy, map[string]*file{}, map[string]string{}, map[string]string{}, map[string]string{}, map[string]map[string]string{}}
+}
+
+func (a *assetFiles) add(f *file, origin string) error {
+	// Clean the path, so equivalent paths conflict too
+	f.path = path.Clean("/" + f.path)[1:]
+
+	if first, ok := a.origins[f.path]; ok {
+		if f.mode.IsDir() && a.files[f.path].mode.IsDir() {
+			return nil
+		}
+
+		switch a.policy {
+		case FirstWins:
+			log.Printf("Skipped asset: %s ...", f.path)
+			return nil
+		case LastWins:
+			log.Printf("Replaced asset: %s ...", f.path)
+		case RenameDuplicates:
+			f.path = a.rename(f.path)
+		default:
+			return &ConflictError{f.path, first, origin}
+		}
+	}
+
+	// Files cannot be the parent directories of other assets, regardless of
+	// the policy
+	if first, ok := a.dirs[f.path]; ok && !f.mode.IsDir() {
+		return &ConflictError{f.path, first, origin}
+	}
+	for dir := path.Dir(f.path); dir != "."; dir = path.Dir(dir) {
+		if p, ok := a.files[dir]; ok && !p.mode.IsDir() {
+			return &ConflictError{dir, a.origins[dir], origin}
+		}
+	}
+
+	log.Printf("Created asset: %s ...", f.path)
+	a.files[f.path] = f
+	a.origins[f.path] = origin
+
+	for dir := path.Dir(f.path); dir != "."; dir = path.Dir(dir) {
+		if _, ok := a.dirs[dir]; ok {
+			break
+		}
+		a.dirs[dir] = origin
+	}
+	return nil
+}
+
+func (a *assetFiles) rename(fp string) string {
+	ext := path.Ext(fp)
+	base := strings.TrimSuffix(fp, ext)
+
+	for i := 1; ; i++ {
+		renamed := base + "-" + strconv.Itoa(i) + ext
+		if _, ok := a.origins[renamed]; !ok {
+			return renamed
+		}
+	}
+}
+
+// ConflictError is returned when two assets have the same path
+type ConflictError struct {
+	Path   string
+	First  string
+	Second string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Path + ": conflict between " + e.First + " and " + e.Second
+}
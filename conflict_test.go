@@ -0,0 +1,135 @@
+package assets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConflictError(t *testing.T) {
+	a := newAssetFiles(FailOnConflict)
+
+	err := a.add(&file{path: "test/file1.txt", data: []byte("File 1")}, "src1")
+	assertEqual(t, err, nil)
+
+	err = a.add(&file{path: "test/file1.txt", data: []byte("File 2")}, "src2")
+	assertEqual(t, err, &ConflictError{"test/file1.txt", "src1", "src2"})
+	assertEqual(t, err.Error(), "test/file1.txt: conflict between src1 and src2")
+}
+
+func TestConflictUncleanPaths(t *testing.T) {
+	a := newAssetFiles(FailOnConflict)
+
+	err := a.add(&file{path: "test/file1.txt", data: []byte("File 1")}, "src1")
+	assertEqual(t, err, nil)
+
+	for _, fp := range []string{"/test/file1.txt", "test//file1.txt", "./test/file1.txt", "test/dir/../file1.txt"} {
+		err = a.add(&file{path: fp, data: []byte("File 2")}, "src2")
+		assertEqual(t, err, &ConflictError{"test/file1.txt", "src1", "src2"})
+	}
+
+	assertEqual(t, len(a.files), 1)
+}
+
+func TestConflictParentPath(t *testing.T) {
+	for _, policy := range []ConflictPolicy{FailOnConflict, FirstWins, LastWins, RenameDuplicates} {
+		a := newAssetFiles(policy)
+
+		err := a.add(&file{path: "test", data: []byte("File 1")}, "src1")
+		assertEqual(t, err, nil)
+
+		err = a.add(&file{path: "test/dir/file2.txt", data: []byte("File 2")}, "src2")
+		assertEqual(t, err, &ConflictError{"test", "src1", "src2"})
+
+		a = newAssetFiles(policy)
+
+		err = a.add(&file{path: "test/dir/file2.txt", data: []byte("File 2")}, "src1")
+		assertEqual(t, err, nil)
+
+		err = a.add(&file{path: "test", data: []byte("File 1")}, "src2")
+		assertEqual(t, err, &ConflictError{"test", "src1", "src2"})
+	}
+
+	// Directories can be the parents of other assets
+	a := newAssetFiles(FailOnConflict)
+
+	err := a.add(&file{path: "test", mode: os.ModeDir | 0755}, "src1")
+	assertEqual(t, err, nil)
+
+	err = a.add(&file{path: "test/file1.txt", data: []byte("File 1")}, "src2")
+	assertEqual(t, err, nil)
+
+	err = a.add(&file{path: "test", mode: os.ModeDir | 0755}, "src3")
+	assertEqual(t, err, nil)
+	assertEqual(t, len(a.files), 2)
+}
+
+func TestRetrieveConflictUncleanPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sources := []*Source{
+		{Path: "/app.txt", Location: filepath.Join(dir, "a.txt")},
+		{Path: "app.txt", Location: filepath.Join(dir, "b.txt")},
+	}
+
+	_, err = RetrieveWithOpts(sources, nil)
+	assertEqual(t, err, &ConflictError{"app.txt", filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")})
+}
+
+func TestConflictFirstWins(t *testing.T) {
+	a := newAssetFiles(FirstWins)
+
+	err := a.add(&file{path: "test/file1.txt", data: []byte("File 1")}, "src1")
+	assertEqual(t, err, nil)
+
+	err = a.add(&file{path: "test/file1.txt", data: []byte("File 2")}, "src2")
+	assertEqual(t, err, nil)
+
+	assertEqual(t, len(a.files), 1)
+	assertEqual(t, a.files["test/file1.txt"].data, []byte("File 1"))
+	assertEqual(t, a.origins["test/file1.txt"], "src1")
+}
+
+func TestConflictLastWins(t *testing.T) {
+	a := newAssetFiles(LastWins)
+
+	err := a.add(&file{path: "test/file1.txt", data: []byte("File 1")}, "src1")
+	assertEqual(t, err, nil)
+
+	err = a.add(&file{path: "test/file1.txt", data: []byte("File 2")}, "src2")
+	assertEqual(t, err, nil)
+
+	assertEqual(t, len(a.files), 1)
+	assertEqual(t, a.files["test/file1.txt"].data, []byte("File 2"))
+	assertEqual(t, a.origins["test/file1.txt"], "src2")
+}
+
+func TestConflictRename(t *testing.T) {
+	a := newAssetFiles(RenameDuplicates)
+
+	err := a.add(&file{path: "test/file1.txt", data: []byte("File 1")}, "src1")
+	assertEqual(t, err, nil)
+
+	err = a.add(&file{path: "test/file1.txt", data: []byte("File 2")}, "src2")
+	assertEqual(t, err, nil)
+
+	err = a.add(&file{path: "test/file1.txt", data: []byte("File 3")}, "src3")
+	assertEqual(t, err, nil)
+
+	assertEqual(t, len(a.files), 3)
+	assertEqual(t, a.files["test/file1.txt"].data, []byte("File 1"))
+	assertEqual(t, a.files["test/file1-1.txt"].data, []byte("File 2"))
+	assertEqual(t, a.files["test/file1-2.txt"].data, []byte("File 3"))
+}
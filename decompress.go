@@ -0,0 +1,93 @@
+package assets
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// CompressionFormat enumerates compression formats.
+type CompressionFormat int
+
+const (
+	// DetectCompression detects the compression format from the file contents.
+	DetectCompression = iota
+	// Gzip is the gzip compression format.
+	Gzip
+	// Bzip2 is the bzip2 compression format.
+	Bzip2
+	// Xz is the xz compression format.
+	Xz
+	// Zstd is the zstandard compression format.
+	Zstd
+)
+
+var (
+	// ErrCompressionUnknown is returned when an invalid compression format is
+	// specified, or the format cannot be detected
+	ErrCompressionUnknown = errors.New("unknown compression format")
+)
+
+// Decompress describes a decompression step for the asset source.
+type Decompress struct {
+	Format CompressionFormat
+}
+
+var magics = []struct {
+	format CompressionFormat
+	magic  []byte
+}{
+	{Gzip, []byte{0x1f, 0x8b}},
+	{Bzip2, []byte("BZh")},
+	{Xz, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{Zstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+func decompress(dec *Decompress, data []byte, lim *Limits) ([]byte, error) {
+	l := newLimiter(lim, len(data))
+
+	format := dec.Format
+	if format == DetectCompression {
+		format = detectCompression(data)
+	}
+
+	switch format {
+	case Gzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return l.read(r)
+	case Bzip2:
+		return l.read(bzip2.NewReader(bytes.NewReader(data)))
+	case Xz:
+		r, err := xz.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return l.read(r)
+	case Zstd:
+		r, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return l.read(r)
+	default:
+		return nil, ErrCompressionUnknown
+	}
+}
+
+func detectCompression(data []byte) CompressionFormat {
+	for _, m := range magics {
+		if bytes.HasPrefix(data, m.magic) {
+			return m.format
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,85 @@
+package assets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+var bzip2Assets = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x6d, 0x38,
+	0xc4, 0x11, 0x00, 0x00, 0x00, 0x05, 0x80, 0x20, 0x00, 0x02, 0x00, 0x0c,
+	0x00, 0x20, 0x00, 0x30, 0xcd, 0x34, 0x18, 0xc8, 0x47, 0x0f, 0x17, 0x72,
+	0x45, 0x38, 0x50, 0x90, 0x6d, 0x38, 0xc4, 0x11,
+}
+
+func TestDecompressGzip(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := gzip.NewWriter(buf)
+	w.Write([]byte("Assets"))
+	w.Close()
+
+	data, err := decompress(&Decompress{Gzip}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, data, []byte("Assets"))
+
+	data, err = decompress(&Decompress{DetectCompression}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, data, []byte("Assets"))
+}
+
+func TestDecompressBzip2(t *testing.T) {
+	data, err := decompress(&Decompress{Bzip2}, bzip2Assets, nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, data, []byte("Assets"))
+
+	data, err = decompress(&Decompress{DetectCompression}, bzip2Assets, nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, data, []byte("Assets"))
+}
+
+func TestDecompressXz(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w, _ := xz.NewWriter(buf)
+	w.Write([]byte("Assets"))
+	w.Close()
+
+	data, err := decompress(&Decompress{Xz}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, data, []byte("Assets"))
+
+	data, err = decompress(&Decompress{DetectCompression}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, data, []byte("Assets"))
+}
+
+func TestDecompressZstd(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w, _ := zstd.NewWriter(buf)
+	w.Write([]byte("Assets"))
+	w.Close()
+
+	data, err := decompress(&Decompress{Zstd}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, data, []byte("Assets"))
+
+	data, err = decompress(&Decompress{DetectCompression}, buf.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, data, []byte("Assets"))
+}
+
+func TestDecompressInvalid(t *testing.T) {
+	_, err := decompress(&Decompress{Gzip}, []byte("1234"), nil)
+	assertNotEqual(t, err, nil)
+}
+
+func TestDecompressUnknown(t *testing.T) {
+	_, err := decompress(&Decompress{DetectCompression}, []byte("Assets"), nil)
+	assertEqual(t, err, ErrCompressionUnknown)
+
+	_, err = decompress(&Decompress{-1}, []byte("Assets"), nil)
+	assertEqual(t, err, ErrCompressionUnknown)
+}
@@ -0,0 +1,70 @@
+package assets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/build/constraint"
+	"go/format"
+	"io/ioutil"
+)
+
+var (
+	// ErrDevFile is returned when Opts.DevFilePath is set without
+	// Opts.BuildTags or Opts.DevDir, or together with Opts.EmbedDir
+	ErrDevFile = errors.New("invalid development file options")
+)
+
+// checkDevOpts verifies the options of the development source file.
+func checkDevOpts(opts *Opts) error {
+	if opts.DevFilePath == "" {
+		return nil
+	}
+
+	if opts.BuildTags == "" || opts.DevDir == "" || opts.EmbedDir != "" {
+		return ErrDevFile
+	}
+
+	return nil
+}
+
+// compileDev generates the development source file, which declares the same
+// variable as the compiled source file with the negated build tags, serving
+// the files of the development directory.
+func compileDev(pkgName string, varName string, opts *Opts) error {
+	src, err := devSource(pkgName, varName, opts)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(opts.DevFilePath, src, 0644)
+}
+
+func devSource(pkgName string, varName string, opts *Opts) ([]byte, error) {
+	var b bytes.Buffer
+
+	expr, err := constraint.Parse("// +build " + opts.BuildTags)
+	if err != nil {
+		return nil, err
+	}
+
+	// Avoid double negation of negated build tags
+	if not, ok := expr.(*constraint.NotExpr); ok {
+		expr = not.X
+	} else {
+		expr = &constraint.NotExpr{X: expr}
+	}
+
+	fmt.Fprintf(&b, "// Code generated by go-assets; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "//go:build %s\n\n", expr)
+	fmt.Fprintf(&b, "package %s\n\nimport \"net/http\"\n\n", pkgName)
+	writeComment(&b, opts.VariableComment)
+	fmt.Fprintf(&b, "var %s http.FileSystem = http.Dir(%q)\n", varName, opts.DevDir)
+
+	// The files in the development directory keep their paths
+	if opts.HashNames != nil {
+		writeHashedPaths(&b, varName, map[string]string{})
+	}
+
+	return format.Source(b.Bytes())
+}
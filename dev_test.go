@@ -0,0 +1,82 @@
+package assets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompileDev(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sources := []*Source{{Path: "retrieve_test.go", Location: "retrieve_test.go"}}
+	filePath := filepath.Join(dir, "assets.go")
+	devFilePath := filepath.Join(dir, "assets_dev.go")
+	opts := &Opts{BuildTags: "!dev", DevFilePath: devFilePath, DevDir: "static"}
+
+	err = Compile(sources, filePath, "assets", "fs", opts)
+	assertEqual(t, err, nil)
+
+	src, err := ioutil.ReadFile(devFilePath)
+	assertEqual(t, err, nil)
+	assertEqual(t, strings.Contains(string(src), "//go:build dev\n"), true)
+	assertEqual(t, strings.Contains(string(src), "package assets\n"), true)
+	assertEqual(t, strings.Contains(string(src), "// fs implements a http.FileSystem.\n"), true)
+	assertEqual(t, strings.Contains(string(src), "var fs http.FileSystem = http.Dir(\"static\")\n"), true)
+
+	// A missing development file is written again
+	err = os.Remove(devFilePath)
+	assertEqual(t, err, nil)
+
+	err = Compile(sources, filePath, "assets", "fs", opts)
+	assertEqual(t, err, nil)
+
+	_, err = os.Stat(devFilePath)
+	assertEqual(t, err, nil)
+}
+
+func TestCompileDevError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sources := []*Source{{Path: "retrieve_test.go", Location: "retrieve_test.go"}}
+	filePath := filepath.Join(dir, "assets.go")
+	devFilePath := filepath.Join(dir, "assets_dev.go")
+
+	for _, opts := range []*Opts{
+		{DevFilePath: devFilePath, DevDir: "static"},
+		{DevFilePath: devFilePath, BuildTags: "!dev"},
+		{DevFilePath: devFilePath, DevDir: "static", BuildTags: "!dev", EmbedDir: "static"},
+	} {
+		err = Compile(sources, filePath, "assets", "fs", opts)
+		assertEqual(t, err, ErrDevFile)
+	}
+}
+
+func TestDevSource(t *testing.T) {
+	tests := []struct {
+		tags       string
+		constraint string
+	}{
+		{"dev", "//go:build !dev\n"},
+		{"!dev", "//go:build dev\n"},
+		{"linux,!dev", "//go:build !(linux && !dev)\n"},
+		{"prod release", "//go:build !(prod || release)\n"},
+	}
+
+	for _, test := range tests {
+		src, err := devSource("assets", "fs", &Opts{BuildTags: test.tags, DevDir: "static", VariableComment: "Assets.\n\nMore."})
+		assertEqual(t, err, nil)
+		assertEqual(t, strings.Contains(string(src), test.constraint), true)
+		assertEqual(t, strings.Contains(string(src), "// Assets.\n//\n// More.\n"), true)
+	}
+}
@@ -0,0 +1,114 @@
+package assets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/build/constraint"
+	"go/format"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ErrEmbedDir is returned when Opts.EmbedDir is not a valid directory
+	// for go:embed, or it has contents not written by Compile
+	ErrEmbedDir = errors.New("invalid embed directory")
+)
+
+// compileEmbed writes the assets to the embed directory next to the source
+// file, and generates the source file embedding that directory.
+func compileEmbed(assets *assetFiles, filePath string, pkgName string, varName string, opts *Opts) error {
+	dir, err := embedDir(filePath, opts.EmbedDir)
+	if err != nil {
+		return err
+	}
+
+	if err := checkEmbedDir(dir, filePath, opts.EmbedDir); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := writeFiles(dir, assets.files); err != nil {
+		return err
+	}
+
+	src, err := embedSource(pkgName, varName, opts)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filePath, src, 0644)
+}
+
+// embedExists reports whether the embed directory of the source file exists,
+// or the source file does not use one.
+func embedExists(filePath string, opts *Opts) bool {
+	if opts.EmbedDir == "" {
+		return true
+	}
+
+	dir, err := embedDir(filePath, opts.EmbedDir)
+	if err != nil {
+		return false
+	}
+
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir()
+}
+
+// checkEmbedDir returns ErrEmbedDir if the embed directory has contents not
+// written by Compile, so it must not be replaced. The directory is written by
+// Compile if the existing source file was generated with the same embed
+// directory.
+func checkEmbedDir(dir string, filePath string, embedDir string) error {
+	infos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) || (err == nil && len(infos) == 0) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	src, err := ioutil.ReadFile(filePath)
+	if err == nil && readFingerprint(filePath) != "" &&
+		bytes.Contains(src, []byte("\n//go:embed all:"+embedDir+"\n")) {
+		return nil
+	}
+
+	return ErrEmbedDir
+}
+
+// embedDir returns the location of the embed directory, which must be
+// a subdirectory of the directory of the source file.
+func embedDir(filePath string, dir string) (string, error) {
+	if dir == "." || !fs.ValidPath(dir) {
+		return "", ErrEmbedDir
+	}
+
+	return filepath.Join(filepath.Dir(filePath), filepath.FromSlash(dir)), nil
+}
+
+// embedSource generates the source file with the embed.FS variable.
+func embedSource(pkgName string, varName string, opts *Opts) ([]byte, error) {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "// Code generated by go-assets; DO NOT EDIT.\n\n")
+
+	if opts.BuildTags != "" {
+		expr, err := constraint.Parse("// +build " + opts.BuildTags)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "//go:build %s\n\n", expr)
+	}
+
+	fmt.Fprintf(&b, "package %s\n\nimport \"embed\"\n\n", pkgName)
+	writeComment(&b, opts.VariableComment)
+	fmt.Fprintf(&b, "//\n//go:embed all:%s\nvar %s embed.FS\n", opts.EmbedDir, varName)
+
+	return format.Source(b.Bytes())
+}
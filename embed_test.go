@@ -0,0 +1,100 @@
+package assets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompileEmbed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mt := time.Unix(1300000000, 0)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "run.sh"), []byte("Run."), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []*Source{
+		{Path: "bin/run.sh", Location: filepath.Join(dir, "run.sh"), ModTime: &mt},
+	}
+	filePath := filepath.Join(dir, "assets.go")
+
+	// Directories not written by Compile are not replaced
+	err = os.MkdirAll(filepath.Join(dir, "static"), 0755)
+	assertEqual(t, err, nil)
+	err = ioutil.WriteFile(filepath.Join(dir, "static", "index.html"), []byte("Index."), 0644)
+	assertEqual(t, err, nil)
+
+	err = Compile(sources, filePath, "assets", "Assets", &Opts{EmbedDir: "static", BuildTags: "!dev"})
+	assertEqual(t, err, ErrEmbedDir)
+
+	_, err = os.Stat(filepath.Join(dir, "static", "index.html"))
+	assertEqual(t, err, nil)
+
+	err = os.Remove(filepath.Join(dir, "static", "index.html"))
+	assertEqual(t, err, nil)
+
+	err = Compile(sources, filePath, "assets", "Assets", &Opts{EmbedDir: "static", BuildTags: "!dev"})
+	assertEqual(t, err, nil)
+
+	src, err := ioutil.ReadFile(filePath)
+	assertEqual(t, err, nil)
+	assertEqual(t, strings.HasPrefix(string(src), fingerprintPrefix), true)
+	assertEqual(t, strings.Contains(string(src), "//go:build !dev\n"), true)
+	assertEqual(t, strings.Contains(string(src), "package assets\n"), true)
+	assertEqual(t, strings.Contains(string(src), "// Assets implements a fs.FS.\n"), true)
+	assertEqual(t, strings.Contains(string(src), "//go:embed all:static\nvar Assets embed.FS\n"), true)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "static", "bin", "run.sh"))
+	assertEqual(t, err, nil)
+	assertEqual(t, data, []byte("Run."))
+
+	info, err := os.Stat(filepath.Join(dir, "static", "bin", "run.sh"))
+	assertEqual(t, err, nil)
+	assertEqual(t, info.Mode(), os.FileMode(0755))
+	assertEqual(t, info.ModTime().Equal(mt), true)
+
+	// Stale files are removed from the embed directory written by Compile
+	err = ioutil.WriteFile(filepath.Join(dir, "static", "stale.txt"), []byte("Stale."), 0644)
+	assertEqual(t, err, nil)
+
+	err = Compile(sources, filePath, "assets", "Assets", &Opts{EmbedDir: "static", BuildTags: "!test"})
+	assertEqual(t, err, nil)
+
+	_, err = os.Stat(filepath.Join(dir, "static", "stale.txt"))
+	assertEqual(t, os.IsNotExist(err), true)
+
+	// A missing embed directory is written again
+	err = os.RemoveAll(filepath.Join(dir, "static"))
+	assertEqual(t, err, nil)
+
+	err = Compile(sources, filePath, "assets", "Assets", &Opts{EmbedDir: "static", BuildTags: "!dev"})
+	assertEqual(t, err, nil)
+
+	_, err = os.Stat(filepath.Join(dir, "static", "bin", "run.sh"))
+	assertEqual(t, err, nil)
+}
+
+func TestCompileEmbedDirError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sources := []*Source{{Path: "retrieve_test.go", Location: "retrieve_test.go"}}
+
+	for _, embedDir := range []string{".", "..", "../static", "/static", "static/"} {
+		err = Compile(sources, filepath.Join(dir, "assets.go"), "assets", "fs", &Opts{EmbedDir: embedDir})
+		assertEqual(t, err, ErrEmbedDir)
+	}
+}
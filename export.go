@@ -0,0 +1,137 @@
+package assets
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// Export retrieves and processes the specified asset sources, and writes
+// them to the specified directory, keeping their modes and modification
+// times. With Opts.Prune, files in the directory that are not assets are
+// removed.
+func Export(sources []*Source, dir string, opts *Opts) error {
+	if opts == nil {
+		opts = &Opts{}
+	}
+
+	assets, err := retrieveAssets(sources, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.Prune {
+		if err := pruneFiles(dir, assets.files); err != nil {
+			return err
+		}
+	}
+
+	return writeFiles(dir, assets.files)
+}
+
+// pruneFiles removes the files and directories in the directory that are
+// neither assets nor contain assets.
+func pruneFiles(dir string, files map[string]*file) error {
+	keep := map[string]bool{}
+	for fp := range files {
+		for fp = path.Clean("/" + fp); fp != "/"; fp = path.Dir(fp) {
+			keep[filepath.FromSlash(fp[1:])] = true
+		}
+	}
+
+	err := filepath.WalkDir(dir, func(fp string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if fp == dir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, fp)
+		if err != nil || rel == "." || keep[rel] {
+			return err
+		}
+
+		if err := os.RemoveAll(fp); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	return err
+}
+
+// writeFiles writes the files to the directory, keeping their modes and
+// modification times.
+func writeFiles(dir string, files map[string]*file) error {
+	paths := []string{}
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		f := files[path]
+		fp := filepath.Join(dir, filepath.FromSlash(path))
+
+		if f.mode.IsDir() {
+			if err := os.MkdirAll(fp, 0755); err != nil {
+				return err
+			}
+		} else {
+			if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+				return err
+			}
+			// Remove the previous file, as it may be read-only
+			if err := os.Remove(fp); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			if err := ioutil.WriteFile(fp, f.data, filePerm(f.mode)); err != nil {
+				return err
+			}
+			if err := os.Chmod(fp, filePerm(f.mode)); err != nil {
+				return err
+			}
+			if err := setModTime(fp, f); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Set the directory times last, as writing the files changes them
+	for i := len(paths) - 1; i >= 0; i-- {
+		f := files[paths[i]]
+		if f.mode.IsDir() {
+			if err := setModTime(filepath.Join(dir, filepath.FromSlash(paths[i])), f); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// setModTime sets the modification time of the written file, if it is known.
+func setModTime(fp string, f *file) error {
+	if f.modTime.IsZero() {
+		return nil
+	}
+
+	return os.Chtimes(fp, f.modTime, f.modTime)
+}
+
+// filePerm returns the permissions to write a file with. Files without
+// permissions (such as some tar entries) are written with mode 0644.
+func filePerm(mode os.FileMode) os.FileMode {
+	if mode.Perm() == 0 {
+		return 0644
+	}
+
+	return mode.Perm()
+}
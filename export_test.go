@@ -0,0 +1,155 @@
+package assets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mt := time.Unix(1300000000, 0)
+	out := filepath.Join(dir, "out")
+
+	err = ioutil.WriteFile(filepath.Join(dir, "run.sh"), []byte("Run."), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []*Source{
+		{Path: "bin/run.sh", Location: filepath.Join(dir, "run.sh"), ModTime: &mt},
+	}
+
+	err = Export(sources, out, nil)
+	assertEqual(t, err, nil)
+
+	data, err := ioutil.ReadFile(filepath.Join(out, "bin", "run.sh"))
+	assertEqual(t, err, nil)
+	assertEqual(t, data, []byte("Run."))
+
+	info, err := os.Stat(filepath.Join(out, "bin", "run.sh"))
+	assertEqual(t, err, nil)
+	assertEqual(t, info.Mode(), os.FileMode(0755))
+	assertEqual(t, info.ModTime().Equal(mt), true)
+
+	// Stale files are kept without Prune
+	err = os.MkdirAll(filepath.Join(out, "stale"), 0755)
+	assertEqual(t, err, nil)
+	err = ioutil.WriteFile(filepath.Join(out, "stale", "stale.txt"), []byte("Stale."), 0644)
+	assertEqual(t, err, nil)
+	err = ioutil.WriteFile(filepath.Join(out, "bin", "stale.txt"), []byte("Stale."), 0644)
+	assertEqual(t, err, nil)
+
+	err = Export(sources, out, nil)
+	assertEqual(t, err, nil)
+
+	_, err = os.Stat(filepath.Join(out, "bin", "stale.txt"))
+	assertEqual(t, err, nil)
+
+	// Stale files are removed with Prune
+	err = Export(sources, out, &Opts{Prune: true})
+	assertEqual(t, err, nil)
+
+	_, err = os.Stat(filepath.Join(out, "bin", "stale.txt"))
+	assertEqual(t, os.IsNotExist(err), true)
+	_, err = os.Stat(filepath.Join(out, "stale"))
+	assertEqual(t, os.IsNotExist(err), true)
+	_, err = os.Stat(filepath.Join(out, "bin", "run.sh"))
+	assertEqual(t, err, nil)
+}
+
+func TestExportPruneMissingDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sources := []*Source{{Path: "retrieve_test.go", Location: "retrieve_test.go"}}
+
+	err = Export(sources, filepath.Join(dir, "out"), &Opts{Prune: true})
+	assertEqual(t, err, nil)
+
+	_, err = os.Stat(filepath.Join(dir, "out", "retrieve_test.go"))
+	assertEqual(t, err, nil)
+}
+
+func TestExportReadOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]*file{
+		"file.txt": {"file.txt", []byte("File"), time.Time{}, 0444},
+	}
+
+	err = writeFiles(dir, files)
+	assertEqual(t, err, nil)
+
+	files["file.txt"].data = []byte("Changed")
+	err = writeFiles(dir, files)
+	assertEqual(t, err, nil)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "file.txt"))
+	assertEqual(t, err, nil)
+	assertEqual(t, data, []byte("Changed"))
+}
+
+func TestExportError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sources := []*Source{{Path: "missing.txt", Location: filepath.Join(dir, "missing.txt")}}
+
+	err = Export(sources, filepath.Join(dir, "out"), nil)
+	assertNotEqual(t, err, nil)
+}
+
+func TestWriteFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mt1 := time.Unix(1300000000, 0)
+	mt2 := time.Unix(1400000000, 0)
+
+	err = writeFiles(dir, map[string]*file{
+		"test":           {"test", nil, mt1, os.ModeDir | 0755},
+		"test/file1.txt": {"test/file1.txt", []byte("File 1"), mt2, 0600},
+		"test/file2.txt": {"test/file2.txt", []byte("File 2"), time.Time{}, 0},
+		"test/empty":     {"test/empty", nil, mt1, os.ModeDir | 0755},
+	})
+	assertEqual(t, err, nil)
+
+	info, err := os.Stat(filepath.Join(dir, "test"))
+	assertEqual(t, err, nil)
+	assertEqual(t, info.IsDir(), true)
+	assertEqual(t, info.ModTime().Equal(mt1), true)
+
+	info, err = os.Stat(filepath.Join(dir, "test", "file1.txt"))
+	assertEqual(t, err, nil)
+	assertEqual(t, info.Mode(), os.FileMode(0600))
+	assertEqual(t, info.ModTime().Equal(mt2), true)
+
+	info, err = os.Stat(filepath.Join(dir, "test", "file2.txt"))
+	assertEqual(t, err, nil)
+	assertEqual(t, info.Mode(), os.FileMode(0644))
+
+	info, err = os.Stat(filepath.Join(dir, "test", "empty"))
+	assertEqual(t, err, nil)
+	assertEqual(t, info.IsDir(), true)
+}
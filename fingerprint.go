@@ -0,0 +1,88 @@
+package assets
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// fingerprintPrefix starts the header line of the generated source file
+// holding the fingerprint.
+const fingerprintPrefix = "// go-assets fingerprint: "
+
+// fingerprint calculates a digest over the asset sources, the options of the
+// generated source file, and the contents of the assets.
+func fingerprint(sources []*Source, pkgName string, varName string, opts *Opts, assets *assetFiles) string {
+	h := sha256.New()
+
+	fmt.Fprintf(h, "package %q\nvariable %q\ntags %q\ncomment %q\nembed %q\ndev %q %q\nmanifest %q\nhash %t\n",
+		pkgName, varName, opts.BuildTags, opts.VariableComment, opts.EmbedDir, opts.DevFilePath, opts.DevDir,
+		opts.ManifestPath, opts.HashNames != nil)
+
+	for _, source := range sources {
+		fmt.Fprintf(h, "source %q %q\n", source.Path, source.Location)
+	}
+
+	paths := []string{}
+	for path := range assets.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		f := assets.files[path]
+		fmt.Fprintf(h, "asset %q %x %d %o\n", path, sha256.Sum256(f.data), f.modTime.UnixNano(), f.mode)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// outputsExist reports whether the other outputs of the source file, such
+// as the embed directory, the development source file and the manifest,
+// exist.
+func outputsExist(filePath string, opts *Opts) bool {
+	return embedExists(filePath, opts) && fileExists(opts.DevFilePath) && fileExists(opts.ManifestPath)
+}
+
+// fileExists reports whether the file exists, or no file is specified.
+func fileExists(filePath string) bool {
+	if filePath == "" {
+		return true
+	}
+
+	_, err := os.Stat(filePath)
+	return err == nil
+}
+
+// readFingerprint returns the fingerprint in the header of the source file,
+// or "" if there is none.
+func readFingerprint(filePath string) string {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, fingerprintPrefix) {
+		return ""
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(line, fingerprintPrefix))
+}
+
+// writeFingerprint adds the fingerprint header to the source file.
+func writeFingerprint(filePath string, fp string) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	data = append([]byte(fingerprintPrefix+fp+"\n"), data...)
+	return ioutil.WriteFile(filePath, data, 0644)
+}
@@ -0,0 +1,94 @@
+package assets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	mt := time.Unix(1300000000, 0)
+	assets := newAssetFiles(FailOnConflict)
+	assets.add(&file{"a.txt", []byte("A"), mt, 0644}, "a.txt")
+	assets.add(&file{"b.txt", []byte("B"), mt, 0644}, "b.txt")
+	sources := []*Source{{Path: "a.txt", Location: "a.txt"}}
+
+	fp := fingerprint(sources, "assets", "fs", &Opts{}, assets)
+	assertEqual(t, len(fp), 64)
+	assertEqual(t, fingerprint(sources, "assets", "fs", &Opts{}, assets), fp)
+
+	assertNotEqual(t, fingerprint(sources, "other", "fs", &Opts{}, assets), fp)
+	assertNotEqual(t, fingerprint(sources, "assets", "other", &Opts{}, assets), fp)
+	assertNotEqual(t, fingerprint(sources, "assets", "fs", &Opts{BuildTags: "dev"}, assets), fp)
+	assertNotEqual(t, fingerprint([]*Source{{Path: "b.txt", Location: "a.txt"}}, "assets", "fs", &Opts{}, assets), fp)
+
+	assets.files["b.txt"].data = []byte("C")
+	assertNotEqual(t, fingerprint(sources, "assets", "fs", &Opts{}, assets), fp)
+}
+
+func TestCompileFingerprint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "assets.txt"), []byte("Assets."), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []*Source{{Path: "assets.txt", Location: filepath.Join(dir, "assets.txt")}}
+	filePath := filepath.Join(dir, "assets.go")
+
+	err = Compile(sources, filePath, "assets", "fs", nil)
+	assertEqual(t, err, nil)
+
+	data, err := ioutil.ReadFile(filePath)
+	assertEqual(t, err, nil)
+	assertEqual(t, strings.HasPrefix(string(data), fingerprintPrefix), true)
+	assertNotEqual(t, readFingerprint(filePath), "")
+
+	// Unchanged inputs leave the source file untouched
+	tampered := append(data, []byte("// Tampered.\n")...)
+	err = ioutil.WriteFile(filePath, tampered, 0644)
+	assertEqual(t, err, nil)
+
+	err = Compile(sources, filePath, "assets", "fs", nil)
+	assertEqual(t, err, nil)
+
+	data, err = ioutil.ReadFile(filePath)
+	assertEqual(t, err, nil)
+	assertEqual(t, string(data), string(tampered))
+
+	// Changed options regenerate the source file
+	err = Compile(sources, filePath, "assets", "fs", &Opts{BuildTags: "dev"})
+	assertEqual(t, err, nil)
+
+	data, err = ioutil.ReadFile(filePath)
+	assertEqual(t, err, nil)
+	assertEqual(t, strings.Contains(string(data), "// Tampered."), false)
+	assertEqual(t, strings.Contains(string(data), "+build dev"), true)
+}
+
+func TestReadFingerprint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filePath := filepath.Join(dir, "assets.go")
+	assertEqual(t, readFingerprint(filePath), "")
+
+	err = ioutil.WriteFile(filePath, []byte("package assets\n"), 0644)
+	assertEqual(t, err, nil)
+	assertEqual(t, readFingerprint(filePath), "")
+
+	err = writeFingerprint(filePath, "abc")
+	assertEqual(t, err, nil)
+	assertEqual(t, readFingerprint(filePath), "abc")
+}
@@ -0,0 +1,15 @@
+module github.com/ZoltanLajosKis/go-assets
+
+go 1.22
+
+require (
+	github.com/andybalholm/brotli v1.2.0
+	github.com/klauspost/compress v1.18.0
+	github.com/shurcooL/vfsgen v0.0.0-20181202132449-6a9ea43bcacd
+	github.com/ulikunitz/xz v0.5.12
+)
+
+require (
+	github.com/shurcooL/httpfs v0.0.0-20190707220628-8d4bc4ba7749 // indirect
+	golang.org/x/tools v0.14.0 // indirect
+)
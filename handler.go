@@ -0,0 +1,393 @@
+package assets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ImmutableCacheControl is a Cache-Control header value for files that never
+// change, such as the files renamed with Opts.HashNames.
+const ImmutableCacheControl = "public, max-age=31536000, immutable"
+
+// CachePolicy sets the Cache-Control header of the files matching a pattern.
+type CachePolicy struct {
+	// Pattern is a path.Match pattern. If it contains a "/", it is matched
+	// against the path of the file (without the leading "/"), otherwise
+	// against the name of the file.
+	Pattern string
+
+	// CacheControl is the value of the Cache-Control header.
+	CacheControl string
+}
+
+// HandlerOpts provides optional parameters to the Handler function.
+type HandlerOpts struct {
+	// CachePolicies set the Cache-Control header of the served files. The
+	// first policy with a matching pattern is used.
+	// Defaults to no Cache-Control header.
+	CachePolicies []CachePolicy
+
+	// IndexNames are the names of the files served for the directories, in
+	// order of preference.
+	// Defaults to "index.html".
+	IndexNames []string
+
+	// CleanURLs serves the ".html" files for the paths without the suffix,
+	// for example "/about.html" for "/about".
+	// Defaults to false.
+	CleanURLs bool
+
+	// Fallback is the path of the file served for the paths without a file,
+	// such as the "/index.html" of single-page applications.
+	// Defaults to no fallback.
+	Fallback string
+
+	// NotFound is the path of the file served with the 404 status code for
+	// the paths without a file, if there is no Fallback.
+	// Defaults to a plain text error message.
+	NotFound string
+
+	// SidecarVariants serves the files with the ".br" and ".gz" suffixes as
+	// the precompressed variants of the files without EncodedFile, such as
+	// the ones of the compiled file systems with Opts.Precompress.
+	// Defaults to false.
+	SidecarVariants bool
+}
+
+// sha256Summer is implemented by the files of the in-memory file systems,
+// which know the SHA-256 digests of their contents.
+type sha256Summer interface {
+	SHA256() string
+}
+
+// gzipByter is implemented by the compressed files of the file systems
+// generated by vfsgen.
+type gzipByter interface {
+	GzipBytes() []byte
+}
+
+// Handler returns a http.Handler serving the files of the file system, with
+// strong ETags calculated from the file contents, If-None-Match support,
+// Cache-Control headers and precompressed variants (see EncodedFile).
+func Handler(fs http.FileSystem, opts *HandlerOpts) http.Handler {
+	if opts == nil {
+		opts = &HandlerOpts{}
+	}
+	if opts.IndexNames == nil {
+		opts.IndexNames = []string{"index.html"}
+	}
+
+	return &handler{fs, opts}
+}
+
+type handler struct {
+	fs   http.FileSystem
+	opts *HandlerOpts
+}
+
+// variant is a representation of a file served by the handler.
+type variant struct {
+	encoding string
+	data     []byte
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	upath := path.Clean("/" + r.URL.Path)
+
+	// Redirect the directories to the paths with a trailing slash, so the
+	// relative links of their index files work
+	if upath != "/" && !strings.HasSuffix(r.URL.Path, "/") && h.isDir(upath) {
+		redirect(w, r, path.Base(upath)+"/")
+		return
+	}
+
+	if name, f, info, ok := h.find(upath); ok {
+		defer f.Close()
+		h.serveFile(w, r, name, f, info)
+		return
+	}
+
+	if h.opts.Fallback != "" {
+		if f, info, err := h.openFile(h.opts.Fallback); err == nil {
+			defer f.Close()
+			h.serveFile(w, r, h.opts.Fallback, f, info)
+			return
+		}
+	}
+
+	if h.opts.NotFound != "" {
+		if f, _, err := h.openFile(h.opts.NotFound); err == nil {
+			defer f.Close()
+			h.serveNotFound(w, r, h.opts.NotFound, f)
+			return
+		}
+	}
+
+	http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+}
+
+// find opens the file served for the path: the file itself, the index file
+// of the directory, or the ".html" file with CleanURLs.
+func (h *handler) find(upath string) (string, http.File, os.FileInfo, bool) {
+	f, info, err := h.open(upath)
+	if err == nil && !info.IsDir() {
+		return upath, f, info, true
+	}
+
+	if err == nil {
+		f.Close()
+		for _, index := range h.opts.IndexNames {
+			name := path.Join(upath, index)
+			if f, info, err := h.openFile(name); err == nil {
+				return name, f, info, true
+			}
+		}
+	}
+
+	if h.opts.CleanURLs && upath != "/" {
+		name := upath + ".html"
+		if f, info, err := h.openFile(name); err == nil {
+			return name, f, info, true
+		}
+	}
+
+	return "", nil, nil, false
+}
+
+// isDir reports whether the path is a directory.
+func (h *handler) isDir(name string) bool {
+	f, info, err := h.open(name)
+	if err != nil {
+		return false
+	}
+	f.Close()
+
+	return info.IsDir()
+}
+
+// redirect redirects the request to the relative path, keeping the query.
+func redirect(w http.ResponseWriter, r *http.Request, target string) {
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+
+	w.Header().Set("Location", target)
+	w.WriteHeader(http.StatusMovedPermanently)
+}
+
+// openFile opens the file, returning an error for directories.
+func (h *handler) openFile(name string) (http.File, os.FileInfo, error) {
+	f, info, err := h.open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if info.IsDir() {
+		f.Close()
+		return nil, nil, os.ErrNotExist
+	}
+
+	return f, info, nil
+}
+
+func (h *handler) open(name string) (http.File, os.FileInfo, error) {
+	f, err := h.fs.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	return f, info, nil
+}
+
+func (h *handler) serveFile(w http.ResponseWriter, r *http.Request, name string, f http.File, info os.FileInfo) {
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	variants := h.variants(name, f)
+	v := negotiate(r.Header.Get("Accept-Encoding"), variants)
+	sum := digest(f, data)
+
+	hdr := w.Header()
+	hdr.Set("Content-Type", contentType(name, data))
+	if cc := h.cacheControl(name); cc != "" {
+		hdr.Set("Cache-Control", cc)
+	}
+	if len(variants) > 0 {
+		hdr.Add("Vary", "Accept-Encoding")
+	}
+
+	if v == nil {
+		hdr.Set("ETag", strconv.Quote(sum))
+	} else {
+		hdr.Set("ETag", strconv.Quote(sum+"-"+v.encoding))
+		hdr.Set("Content-Encoding", v.encoding)
+		data = v.data
+	}
+
+	http.ServeContent(w, r, name, info.ModTime(), bytes.NewReader(data))
+}
+
+// serveNotFound serves the file with the 404 status code, without
+// conditional requests and precompressed variants.
+func (h *handler) serveNotFound(w http.ResponseWriter, r *http.Request, name string, f http.File) {
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	hdr := w.Header()
+	hdr.Set("Content-Type", contentType(name, data))
+	hdr.Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusNotFound)
+
+	if r.Method != http.MethodHead {
+		w.Write(data)
+	}
+}
+
+// contentType returns the content type of the file, based on its extension
+// or its contents.
+func contentType(name string, data []byte) string {
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		return ctype
+	}
+
+	return http.DetectContentType(data)
+}
+
+// digest returns the content digest of the file. It is calculated from the
+// data, unless the file knows it already.
+func digest(f http.File, data []byte) string {
+	if s, ok := f.(sha256Summer); ok {
+		return s.SHA256()
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheControl returns the Cache-Control header value of the file.
+func (h *handler) cacheControl(name string) string {
+	name = strings.TrimPrefix(name, "/")
+
+	for _, p := range h.opts.CachePolicies {
+		target := name
+		if !strings.Contains(p.Pattern, "/") {
+			target = path.Base(name)
+		}
+		if ok, _ := path.Match(p.Pattern, target); ok {
+			return p.CacheControl
+		}
+	}
+
+	return ""
+}
+
+// variants returns the precompressed variants of the file, in order of
+// preference. They are taken from EncodedFile, or the files with the ".br"
+// and ".gz" suffixes if HandlerOpts.SidecarVariants is set, or from
+// GzipBytes of the file systems generated by vfsgen.
+func (h *handler) variants(name string, f http.File) []*variant {
+	variants := []*variant{}
+
+	if enc, ok := f.(EncodedFile); ok {
+		for _, e := range enc.Encodings() {
+			if data, ok := enc.EncodedBytes(e); ok {
+				variants = append(variants, &variant{e, data})
+			}
+		}
+		return variants
+	}
+
+	if h.opts.SidecarVariants {
+		for _, e := range encodings {
+			vf, info, err := h.open(name + e.suffix)
+			if err != nil {
+				continue
+			}
+			data, err := ioutil.ReadAll(vf)
+			vf.Close()
+			if err == nil && !info.IsDir() {
+				variants = append(variants, &variant{e.name, data})
+			}
+		}
+	}
+
+	if gz, ok := f.(gzipByter); ok && len(variants) == 0 {
+		variants = append(variants, &variant{GzipEncoding, gz.GzipBytes()})
+	}
+
+	return variants
+}
+
+// negotiate returns the preferred variant accepted by the Accept-Encoding
+// header, or nil if none of them is accepted.
+func negotiate(accept string, variants []*variant) *variant {
+	for _, v := range variants {
+		if acceptsEncoding(accept, v.encoding) {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header accepts the
+// content coding. An explicit quality value of the content coding takes
+// precedence over the one of "*".
+func acceptsEncoding(accept string, encoding string) bool {
+	if q, ok := qualityValue(accept, encoding); ok {
+		return q > 0
+	}
+	if q, ok := qualityValue(accept, "*"); ok {
+		return q > 0
+	}
+
+	return false
+}
+
+// qualityValue returns the quality value of the content coding in the
+// Accept-Encoding header, or false if it is not listed.
+func qualityValue(accept string, encoding string) (float64, bool) {
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(params[0]), encoding) {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				q, _ = strconv.ParseFloat(param[2:], 64)
+			}
+		}
+		return q, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,335 @@
+package assets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestHandler(t *testing.T, opts *HandlerOpts) http.Handler {
+	mt := time.Unix(1300000000, 0)
+	text := []byte(strings.Repeat("Assets. ", 100))
+
+	assets := newAssetFiles(FailOnConflict)
+	assets.add(&file{"static/app.css", text, mt, 0644}, "app.zip")
+	assets.add(&file{"static/logo.bin", []byte("Logo"), mt, 0644}, "app.zip")
+	assets.add(&file{"index.html", []byte("<html></html>"), mt, 0644}, "app.zip")
+
+	css, err := GlobFilter("*/*.css")
+	assertEqual(t, err, nil)
+	err = assets.precompress(css)
+	assertEqual(t, err, nil)
+
+	return Handler(newMemFS(assets.files, assets.variants), opts)
+}
+
+func serve(h http.Handler, method string, target string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	h := newTestHandler(t, nil)
+
+	w := serve(h, "GET", "/static/logo.bin", nil)
+	assertEqual(t, w.Code, http.StatusOK)
+	assertEqual(t, w.Body.String(), "Logo")
+	assertEqual(t, w.Header().Get("ETag"), `"d707dc2f1936efd8707060cbe7cdeaba8f250967ecd32fcd56e2491c7f18e52c"`)
+	assertEqual(t, w.Header().Get("Cache-Control"), "")
+	assertEqual(t, w.Header().Get("Vary"), "")
+	assertEqual(t, w.Header().Get("Last-Modified"), "Sun, 13 Mar 2011 07:06:40 GMT")
+
+	etag := w.Header().Get("ETag")
+
+	w = serve(h, "GET", "/static/logo.bin", map[string]string{"If-None-Match": etag})
+	assertEqual(t, w.Code, http.StatusNotModified)
+
+	w = serve(h, "GET", "/static/logo.bin", map[string]string{"If-None-Match": `"other"`})
+	assertEqual(t, w.Code, http.StatusOK)
+
+	w = serve(h, "GET", "/", nil)
+	assertEqual(t, w.Code, http.StatusOK)
+	assertEqual(t, w.Body.String(), "<html></html>")
+	assertEqual(t, w.Header().Get("Content-Type"), "text/html; charset=utf-8")
+
+	w = serve(h, "GET", "/static/missing.css", nil)
+	assertEqual(t, w.Code, http.StatusNotFound)
+
+	w = serve(h, "GET", "/static/", nil)
+	assertEqual(t, w.Code, http.StatusNotFound)
+
+	w = serve(h, "POST", "/index.html", nil)
+	assertEqual(t, w.Code, http.StatusMethodNotAllowed)
+
+	w = serve(h, "HEAD", "/index.html", nil)
+	assertEqual(t, w.Code, http.StatusOK)
+	assertEqual(t, w.Body.Len(), 0)
+}
+
+func TestHandlerEncoding(t *testing.T) {
+	h := newTestHandler(t, nil)
+
+	w := serve(h, "GET", "/static/app.css", nil)
+	assertEqual(t, w.Code, http.StatusOK)
+	assertEqual(t, w.Header().Get("Content-Encoding"), "")
+	assertEqual(t, w.Header().Get("Content-Type"), "text/css; charset=utf-8")
+	assertEqual(t, w.Header().Get("Vary"), "Accept-Encoding")
+	assertEqual(t, w.Body.String(), strings.Repeat("Assets. ", 100))
+	etag := w.Header().Get("ETag")
+
+	w = serve(h, "GET", "/static/app.css", map[string]string{"Accept-Encoding": "gzip, deflate, br"})
+	assertEqual(t, w.Code, http.StatusOK)
+	assertEqual(t, w.Header().Get("Content-Encoding"), "br")
+	assertEqual(t, w.Header().Get("Content-Type"), "text/css; charset=utf-8")
+	assertEqual(t, w.Header().Get("ETag"), strings.TrimSuffix(etag, `"`)+`-br"`)
+
+	w = serve(h, "GET", "/static/app.css", map[string]string{"Accept-Encoding": "gzip"})
+	assertEqual(t, w.Header().Get("Content-Encoding"), "gzip")
+	dec, err := decompress(&Decompress{Gzip}, w.Body.Bytes(), nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, string(dec), strings.Repeat("Assets. ", 100))
+
+	w = serve(h, "GET", "/static/app.css", map[string]string{"Accept-Encoding": "*, br;q=0"})
+	assertEqual(t, w.Header().Get("Content-Encoding"), "gzip")
+
+	w = serve(h, "GET", "/static/app.css", map[string]string{"Accept-Encoding": "identity"})
+	assertEqual(t, w.Header().Get("Content-Encoding"), "")
+
+	w = serve(h, "GET", "/static/app.css", map[string]string{
+		"Accept-Encoding": "br",
+		"If-None-Match":   strings.TrimSuffix(etag, `"`) + `-br"`,
+	})
+	assertEqual(t, w.Code, http.StatusNotModified)
+
+	// The ETag of another representation does not match
+	w = serve(h, "GET", "/static/app.css", map[string]string{
+		"Accept-Encoding": "br",
+		"If-None-Match":   etag,
+	})
+	assertEqual(t, w.Code, http.StatusOK)
+}
+
+func TestHandlerCachePolicies(t *testing.T) {
+	h := newTestHandler(t, &HandlerOpts{CachePolicies: []CachePolicy{
+		{"static/*.css", ImmutableCacheControl},
+		{"*.html", "no-cache"},
+		{"*", "public, max-age=3600"},
+	}})
+
+	w := serve(h, "GET", "/static/app.css", nil)
+	assertEqual(t, w.Header().Get("Cache-Control"), ImmutableCacheControl)
+
+	w = serve(h, "GET", "/", nil)
+	assertEqual(t, w.Header().Get("Cache-Control"), "no-cache")
+
+	w = serve(h, "GET", "/static/logo.bin", nil)
+	assertEqual(t, w.Header().Get("Cache-Control"), "public, max-age=3600")
+}
+
+// gzipFS serves files implementing GzipBytes, like the file systems generated
+// by vfsgen.
+type gzipFS struct {
+	http.FileSystem
+}
+
+type gzipFile struct {
+	http.File
+	gz []byte
+}
+
+func (f *gzipFile) GzipBytes() []byte {
+	return f.gz
+}
+
+func (fs gzipFS) Open(name string) (http.File, error) {
+	f, err := fs.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &gzipFile{f, []byte("Gzipped")}, nil
+}
+
+func TestHandlerGzipBytes(t *testing.T) {
+	mt := time.Unix(1300000000, 0)
+	h := Handler(gzipFS{newMemFS(map[string]*file{
+		"app.js": {"app.js", []byte("App"), mt, 0644},
+	}, nil)}, nil)
+
+	w := serve(h, "GET", "/app.js", map[string]string{"Accept-Encoding": "gzip"})
+	assertEqual(t, w.Header().Get("Content-Encoding"), "gzip")
+	assertEqual(t, w.Body.String(), "Gzipped")
+
+	w = serve(h, "GET", "/app.js", nil)
+	assertEqual(t, w.Header().Get("Content-Encoding"), "")
+	assertEqual(t, w.Body.String(), "App")
+}
+
+// plainFS serves files without EncodedFile, like the file systems generated
+// by vfsgen or http.Dir.
+type plainFS struct {
+	http.FileSystem
+}
+
+type plainFile struct {
+	http.File
+}
+
+func (fs plainFS) Open(name string) (http.File, error) {
+	f, err := fs.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return plainFile{f}, nil
+}
+
+func TestHandlerSidecarVariants(t *testing.T) {
+	mt := time.Unix(1300000000, 0)
+	h := Handler(plainFS{newMemFS(map[string]*file{
+		"app.js":    {"app.js", []byte("App"), mt, 0644},
+		"app.js.gz": {"app.js.gz", []byte("Gzipped"), mt, 0644},
+	}, nil)}, &HandlerOpts{SidecarVariants: true})
+
+	w := serve(h, "GET", "/app.js", map[string]string{"Accept-Encoding": "gzip, br"})
+	assertEqual(t, w.Header().Get("Content-Encoding"), "gzip")
+	assertEqual(t, w.Body.String(), "Gzipped")
+
+	// Files with the suffixes are not variants by default
+	h = Handler(plainFS{newMemFS(map[string]*file{
+		"app.js":    {"app.js", []byte("App"), mt, 0644},
+		"app.js.gz": {"app.js.gz", []byte("Gzipped"), mt, 0644},
+	}, nil)}, nil)
+
+	w = serve(h, "GET", "/app.js", map[string]string{"Accept-Encoding": "gzip, br"})
+	assertEqual(t, w.Header().Get("Content-Encoding"), "")
+	assertEqual(t, w.Body.String(), "App")
+}
+
+func TestHandlerChangedFile(t *testing.T) {
+	mt := time.Unix(1300000000, 0)
+	files := map[string]*file{
+		"app.js": {"app.js", []byte("App"), mt, 0644},
+	}
+	h := Handler(plainFS{newMemFS(files, nil)}, nil)
+
+	w := serve(h, "GET", "/app.js", nil)
+	etag := w.Header().Get("ETag")
+
+	// Same size and modification time, but different contents
+	files["app.js"].data = []byte("Bpp")
+
+	w = serve(h, "GET", "/app.js", map[string]string{"If-None-Match": etag})
+	assertEqual(t, w.Code, http.StatusOK)
+	assertEqual(t, w.Body.String(), "Bpp")
+	assertNotEqual(t, w.Header().Get("ETag"), etag)
+}
+
+func newSiteHandler(opts *HandlerOpts) http.Handler {
+	mt := time.Unix(1300000000, 0)
+
+	return Handler(newMemFS(map[string]*file{
+		"index.html":        {"index.html", []byte("Index"), mt, 0644},
+		"about.html":        {"about.html", []byte("About"), mt, 0644},
+		"404.html":          {"404.html", []byte("Not found"), mt, 0644},
+		"docs/default.htm":  {"docs/default.htm", []byte("Docs"), mt, 0644},
+		"docs/guide/a.html": {"docs/guide/a.html", []byte("Guide"), mt, 0644},
+	}, nil), opts)
+}
+
+func TestHandlerIndexNames(t *testing.T) {
+	h := newSiteHandler(&HandlerOpts{IndexNames: []string{"index.html", "default.htm"}})
+
+	w := serve(h, "GET", "/", nil)
+	assertEqual(t, w.Body.String(), "Index")
+
+	w = serve(h, "GET", "/docs/", nil)
+	assertEqual(t, w.Code, http.StatusOK)
+	assertEqual(t, w.Body.String(), "Docs")
+
+	w = serve(h, "GET", "/docs/guide/", nil)
+	assertEqual(t, w.Code, http.StatusNotFound)
+
+	// Directories without a trailing slash are redirected
+	w = serve(h, "GET", "/docs?lang=en", nil)
+	assertEqual(t, w.Code, http.StatusMovedPermanently)
+	assertEqual(t, w.Header().Get("Location"), "docs/?lang=en")
+
+	w = serve(h, "GET", "/docs/guide", nil)
+	assertEqual(t, w.Code, http.StatusMovedPermanently)
+	assertEqual(t, w.Header().Get("Location"), "guide/")
+
+	h = newSiteHandler(nil)
+
+	w = serve(h, "GET", "/docs/", nil)
+	assertEqual(t, w.Code, http.StatusNotFound)
+}
+
+func TestHandlerCleanURLs(t *testing.T) {
+	h := newSiteHandler(&HandlerOpts{CleanURLs: true})
+
+	w := serve(h, "GET", "/about", nil)
+	assertEqual(t, w.Code, http.StatusOK)
+	assertEqual(t, w.Body.String(), "About")
+	assertEqual(t, w.Header().Get("Content-Type"), "text/html; charset=utf-8")
+
+	w = serve(h, "GET", "/about.html", nil)
+	assertEqual(t, w.Body.String(), "About")
+
+	w = serve(h, "GET", "/docs/guide/a", nil)
+	assertEqual(t, w.Body.String(), "Guide")
+
+	w = serve(h, "GET", "/contact", nil)
+	assertEqual(t, w.Code, http.StatusNotFound)
+
+	h = newSiteHandler(nil)
+
+	w = serve(h, "GET", "/about", nil)
+	assertEqual(t, w.Code, http.StatusNotFound)
+}
+
+func TestHandlerFallback(t *testing.T) {
+	h := newSiteHandler(&HandlerOpts{Fallback: "/index.html", NotFound: "/404.html"})
+
+	w := serve(h, "GET", "/users/42", nil)
+	assertEqual(t, w.Code, http.StatusOK)
+	assertEqual(t, w.Body.String(), "Index")
+	assertEqual(t, w.Header().Get("Content-Type"), "text/html; charset=utf-8")
+
+	w = serve(h, "GET", "/about.html", nil)
+	assertEqual(t, w.Body.String(), "About")
+
+	// A missing fallback file falls through to the next option
+	h = newSiteHandler(&HandlerOpts{Fallback: "/missing.html", NotFound: "/404.html"})
+
+	w = serve(h, "GET", "/users/42", nil)
+	assertEqual(t, w.Code, http.StatusNotFound)
+	assertEqual(t, w.Body.String(), "Not found")
+}
+
+func TestHandlerNotFound(t *testing.T) {
+	h := newSiteHandler(&HandlerOpts{NotFound: "/404.html"})
+
+	w := serve(h, "GET", "/missing", nil)
+	assertEqual(t, w.Code, http.StatusNotFound)
+	assertEqual(t, w.Body.String(), "Not found")
+	assertEqual(t, w.Header().Get("Content-Type"), "text/html; charset=utf-8")
+	assertEqual(t, w.Header().Get("Content-Length"), "9")
+	assertEqual(t, w.Header().Get("ETag"), "")
+
+	w = serve(h, "HEAD", "/missing", nil)
+	assertEqual(t, w.Code, http.StatusNotFound)
+	assertEqual(t, w.Body.Len(), 0)
+
+	h = newSiteHandler(&HandlerOpts{NotFound: "/docs"})
+
+	w = serve(h, "GET", "/missing", nil)
+	assertEqual(t, w.Code, http.StatusNotFound)
+	assertEqual(t, w.Body.String(), "Not Found\n")
+}
@@ -0,0 +1,98 @@
+package assets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"path"
+	"sort"
+)
+
+// hashNames renames the asset files selected by the filter to include
+// a fragment of their content hash, and records the renamed paths.
+func (a *assetFiles) hashNames(filter PathFilter) error {
+	paths := []string{}
+	for fp, f := range a.files {
+		if !f.mode.IsDir() && filter(fp) {
+			paths = append(paths, fp)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, fp := range paths {
+		f := a.files[fp]
+		origin := a.origins[fp]
+		delete(a.files, fp)
+		delete(a.origins, fp)
+
+		f.path = hashedPath(fp, f.data)
+		if first, ok := a.origins[f.path]; ok {
+			return &ConflictError{f.path, first, origin}
+		}
+
+		a.files[f.path] = f
+		a.origins[f.path] = origin
+		a.hashed[fp] = f.path
+	}
+
+	return nil
+}
+
+// hashedPath returns the path with the first 8 hexadecimal digits of the
+// SHA-256 hash of the data inserted before the extension, for example
+// "app.3f9a1c2d.css".
+func hashedPath(fp string, data []byte) string {
+	sum := sha256.Sum256(data)
+	ext := path.Ext(fp)
+	if ext == path.Base(fp) {
+		ext = ""
+	}
+
+	return fp[:len(fp)-len(ext)] + "." + hex.EncodeToString(sum[:4]) + ext
+}
+
+// writeHashedPaths writes the map of the hashed paths, and the function
+// looking them up, as source code.
+func writeHashedPaths(b *bytes.Buffer, varName string, hashed map[string]string) {
+	paths := []string{}
+	for fp := range hashed {
+		paths = append(paths, fp)
+	}
+	sort.Strings(paths)
+
+	fmt.Fprintf(b, "\n// %sPaths maps the asset paths to their paths with content hashes.\n", varName)
+	fmt.Fprintf(b, "var %sPaths = map[string]string{\n", varName)
+	for _, fp := range paths {
+		fmt.Fprintf(b, "%q: %q,\n", fp, hashed[fp])
+	}
+	fmt.Fprintf(b, "}\n")
+
+	fmt.Fprintf(b, "\n// %sPath returns the path with content hash of the asset path, or the\n", varName)
+	fmt.Fprintf(b, "// asset path if it has none.\n")
+	fmt.Fprintf(b, "func %sPath(p string) string {\n", varName)
+	fmt.Fprintf(b, "if len(p) > 0 && p[0] == '/' {\n")
+	fmt.Fprintf(b, "if h, ok := %sPaths[p[1:]]; ok {\nreturn \"/\" + h\n}\nreturn p\n}\n", varName)
+	fmt.Fprintf(b, "if h, ok := %sPaths[p]; ok {\nreturn h\n}\nreturn p\n}\n", varName)
+}
+
+// appendHashedPaths adds the map of the hashed paths, and the function
+// looking them up, to the source file.
+func appendHashedPaths(filePath string, varName string, hashed map[string]string) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	b := bytes.NewBuffer(data)
+	writeHashedPaths(b, varName, hashed)
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filePath, src, 0644)
+}
@@ -0,0 +1,111 @@
+package assets
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHashedPath(t *testing.T) {
+	data := []byte("A")
+
+	assertEqual(t, hashedPath("app.css", data), "app.559aead0.css")
+	assertEqual(t, hashedPath("static/app.min.js", data), "static/app.min.559aead0.js")
+	assertEqual(t, hashedPath("LICENSE", data), "LICENSE.559aead0")
+	assertEqual(t, hashedPath("static/.env", data), "static/.env.559aead0")
+}
+
+func TestHashNames(t *testing.T) {
+	mt := time.Unix(1300000000, 0)
+
+	assets := newAssetFiles(FailOnConflict)
+	assets.add(&file{"static/app.css", []byte("A"), mt, 0644}, "app.zip")
+	assets.add(&file{"static/index.html", []byte("B"), mt, 0644}, "app.zip")
+	assets.add(&file{"static/css", nil, mt, os.ModeDir | 0755}, "app.zip")
+
+	html, err := GlobMap("*/*.html")
+	assertEqual(t, err, nil)
+	err = assets.hashNames(KeptBy(Exclude(html)))
+	assertEqual(t, err, nil)
+
+	assertEqual(t, len(assets.files), 3)
+	assertEqual(t, assets.files["static/app.559aead0.css"].path, "static/app.559aead0.css")
+	assertEqual(t, assets.origins["static/app.559aead0.css"], "app.zip")
+	assertNotEqual(t, assets.files["static/index.html"], (*file)(nil))
+	assertNotEqual(t, assets.files["static/css"], (*file)(nil))
+	assertEqual(t, assets.hashed, map[string]string{"static/app.css": "static/app.559aead0.css"})
+
+	// Renamed paths conflicting with other assets
+	assets = newAssetFiles(FailOnConflict)
+	assets.add(&file{"app.css", []byte("A"), mt, 0644}, "app.zip")
+	assets.add(&file{"app.559aead0.css", []byte("A"), mt, 0644}, "other.zip")
+
+	css, err := GlobFilter("app.css")
+	assertEqual(t, err, nil)
+	err = assets.hashNames(css)
+	assertEqual(t, err, &ConflictError{"app.559aead0.css", "other.zip", "app.zip"})
+}
+
+func TestWriteHashedPaths(t *testing.T) {
+	var b bytes.Buffer
+	writeHashedPaths(&b, "fs", map[string]string{"b.js": "b.df7e70e5.js", "a.css": "a.559aead0.css"})
+
+	src := b.String()
+	assertEqual(t, strings.Contains(src, "var fsPaths = map[string]string{\n\"a.css\": \"a.559aead0.css\",\n\"b.js\": \"b.df7e70e5.js\",\n}\n"), true)
+	assertEqual(t, strings.Contains(src, "func fsPath(p string) string {\n"), true)
+}
+
+func TestCompileHashNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "app.css"), []byte("A"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	css, err := GlobFilter("*/*.css")
+	assertEqual(t, err, nil)
+
+	sources := []*Source{{Path: "static/app.css", Location: filepath.Join(dir, "app.css")}}
+	filePath := filepath.Join(dir, "assets.go")
+	devFilePath := filepath.Join(dir, "assets_dev.go")
+
+	for _, embedDir := range []string{"", "static"} {
+		opts := &Opts{
+			BuildTags:   "!dev",
+			EmbedDir:    embedDir,
+			HashNames:   css,
+			DevFilePath: devFilePath,
+			DevDir:      "static",
+		}
+		if embedDir != "" {
+			opts.DevFilePath = ""
+		}
+
+		err = Compile(sources, filePath, "assets", "Assets", opts)
+		assertEqual(t, err, nil)
+
+		src, err := ioutil.ReadFile(filePath)
+		assertEqual(t, err, nil)
+		assertEqual(t, strings.Contains(string(src), "var AssetsPaths = map[string]string{\n\t\"static/app.css\": \"static/app.559aead0.css\",\n}\n"), true)
+		assertEqual(t, strings.Contains(string(src), "func AssetsPath(p string) string {\n"), true)
+	}
+
+	src, err := ioutil.ReadFile(devFilePath)
+	assertEqual(t, err, nil)
+	assertEqual(t, strings.Contains(string(src), "var AssetsPaths = map[string]string{}\n"), true)
+	assertEqual(t, strings.Contains(string(src), "func AssetsPath(p string) string {\n"), true)
+
+	fs, err := RetrieveFS(sources, &Opts{HashNames: css})
+	assertEqual(t, err, nil)
+	_, err = fs.Open("static/app.559aead0.css")
+	assertEqual(t, err, nil)
+}
@@ -0,0 +1,135 @@
+package assets
+
+import (
+	"io/fs"
+	"path"
+)
+
+// RetrieveFS is like RetrieveWithOpts, but returns the assets using an
+// io/fs.FS interface. The returned file system also implements the
+// fs.ReadDirFS, fs.StatFS and fs.ReadFileFS interfaces.
+func RetrieveFS(sources []*Source, opts *Opts) (fs.FS, error) {
+	if opts == nil {
+		opts = &Opts{}
+	}
+
+	assets, err := retrieveAssets(sources, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ioFS{newMemFS(assets.files, assets.variants)}, nil
+}
+
+// ioFS is an io/fs.FS serving the files of a memFS.
+type ioFS struct {
+	m *memFS
+}
+
+// memPath returns the memFS path of a valid io/fs path.
+func memPath(op string, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	return path.Clean("/" + name), nil
+}
+
+func (f *ioFS) Open(name string) (fs.File, error) {
+	fp, err := memPath("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.stat("open", name, fp)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := f.m.Open(fp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ioFile{file.(*memFile), info}, nil
+}
+
+func (f *ioFS) Stat(name string) (fs.FileInfo, error) {
+	fp, err := memPath("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.stat("stat", name, fp)
+}
+
+func (f *ioFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	file, err := f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	dir, ok := file.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	return dir.ReadDir(-1)
+}
+
+func (f *ioFS) ReadFile(name string) ([]byte, error) {
+	fp, err := memPath("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.stat("readfile", name, fp)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+
+	return append([]byte(nil), f.m.files[fp].data...), nil
+}
+
+// stat returns the file information of a memFS path. The root directory is
+// named "." as required by io/fs.
+func (f *ioFS) stat(op string, name string, fp string) (fs.FileInfo, error) {
+	info, err := f.m.stat(fp)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	if fp == "/" {
+		return &fileInfo{".", 0, info.Mode(), info.ModTime()}, nil
+	}
+
+	return info, nil
+}
+
+// ioFile is a file or directory opened from an ioFS.
+type ioFile struct {
+	*memFile
+	info fs.FileInfo
+}
+
+func (f *ioFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *ioFile) ReadDir(count int) ([]fs.DirEntry, error) {
+	infos, err := f.memFile.Readdir(count)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []fs.DirEntry{}
+	for _, info := range infos {
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,76 @@
+package assets
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestIOFS(t *testing.T) {
+	mt := time.Unix(1300000000, 0)
+
+	fsys := &ioFS{newMemFS(map[string]*file{
+		"test/file1.txt":     {"test/file1.txt", []byte("File 1"), mt, 0644},
+		"test/run.sh":        {"test/run.sh", []byte("File 2"), mt, 0755},
+		"test/dir/file3.txt": {"test/dir/file3.txt", []byte("File 3"), mt, 0600},
+		"test/empty":         {"test/empty", nil, mt, os.ModeDir | 0755},
+	}, nil)}
+
+	err := fstest.TestFS(fsys, "test/file1.txt", "test/run.sh", "test/dir/file3.txt", "test/empty")
+	assertEqual(t, err, nil)
+
+	data, err := fs.ReadFile(fsys, "test/dir/file3.txt")
+	assertEqual(t, err, nil)
+	assertEqual(t, data, []byte("File 3"))
+
+	info, err := fs.Stat(fsys, "test/run.sh")
+	assertEqual(t, err, nil)
+	assertEqual(t, info.Mode(), os.FileMode(0755))
+	assertEqual(t, info.ModTime(), mt)
+
+	entries, err := fs.ReadDir(fsys, "test")
+	assertEqual(t, err, nil)
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assertEqual(t, names, []string{"dir", "empty", "file1.txt", "run.sh"})
+
+	_, err = fs.ReadFile(fsys, "test/file4.txt")
+	assertEqual(t, os.IsNotExist(err), true)
+
+	_, err = fs.ReadFile(fsys, "/test/file1.txt")
+	assertNotEqual(t, err, nil)
+
+	_, err = fs.ReadFile(fsys, "test")
+	assertNotEqual(t, err, nil)
+}
+
+func TestRetrieveFS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "assets.txt"), []byte("Assets."), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []*Source{{Path: "static/assets.txt", Location: filepath.Join(dir, "assets.txt")}}
+
+	fsys, err := RetrieveFS(sources, nil)
+	assertEqual(t, err, nil)
+
+	data, err := fs.ReadFile(fsys, "static/assets.txt")
+	assertEqual(t, err, nil)
+	assertEqual(t, data, []byte("Assets."))
+
+	_, err = RetrieveFS([]*Source{{Path: "missing.txt", Location: filepath.Join(dir, "missing.txt")}}, nil)
+	assertNotEqual(t, err, nil)
+}
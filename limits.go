@@ -0,0 +1,140 @@
+package assets
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+var (
+	// ErrDownloadSize is returned when a downloaded asset source exceeds the
+	// maximum download size
+	ErrDownloadSize = errors.New("download size limit exceeded")
+	// ErrEntrySize is returned when an archive entry or a decompressed file
+	// exceeds the maximum entry size
+	ErrEntrySize = errors.New("entry size limit exceeded")
+	// ErrTotalSize is returned when the files extracted from an archive exceed
+	// the maximum total size
+	ErrTotalSize = errors.New("total size limit exceeded")
+	// ErrEntryCount is returned when an archive contains more entries than the
+	// maximum entry count
+	ErrEntryCount = errors.New("entry count limit exceeded")
+	// ErrRatio is returned when an archive or a compressed file exceeds the
+	// maximum compression ratio
+	ErrRatio = errors.New("compression ratio limit exceeded")
+)
+
+// Limits restricts the resources used while retrieving and extracting asset
+// sources. Zero values mean no limit.
+type Limits struct {
+	// MaxDownloadSize is the maximum size of a downloaded asset source in bytes.
+	MaxDownloadSize int64
+
+	// MaxEntrySize is the maximum uncompressed size of an archive entry or a
+	// decompressed file in bytes.
+	MaxEntrySize int64
+
+	// MaxTotalSize is the maximum total uncompressed size of the files
+	// extracted from an archive, including its nested archives, in bytes.
+	MaxTotalSize int64
+
+	// MaxEntries is the maximum number of entries in an archive, including
+	// its nested archives.
+	MaxEntries int
+
+	// MaxRatio is the maximum ratio between the uncompressed size and the
+	// compressed size of an archive (including its nested archives) or
+	// a compressed file.
+	MaxRatio int64
+}
+
+// limiter enforces the limits on the data extracted from an archive (with
+// its nested archives) or a compressed file.
+type limiter struct {
+	lim     *Limits
+	size    int64
+	entries int
+	total   int64
+}
+
+func newLimiter(lim *Limits, size int) *limiter {
+	if lim == nil {
+		lim = &Limits{}
+	}
+
+	return &limiter{lim: lim, size: int64(size)}
+}
+
+// entry accounts for a new entry in the archive.
+func (l *limiter) entry() error {
+	l.entries++
+	if l.lim.MaxEntries > 0 && l.entries > l.lim.MaxEntries {
+		return ErrEntryCount
+	}
+
+	return nil
+}
+
+// read reads the data of an entry and accounts for its size.
+func (l *limiter) read(r io.Reader) ([]byte, error) {
+	max := int64(-1)
+	if l.lim.MaxEntrySize > 0 {
+		max = l.lim.MaxEntrySize
+	}
+	if l.lim.MaxTotalSize > 0 {
+		max = minLimit(max, l.lim.MaxTotalSize-l.total)
+	}
+	if l.lim.MaxRatio > 0 {
+		max = minLimit(max, l.lim.MaxRatio*l.size-l.total)
+	}
+
+	data, err := readLimited(r, max, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	size := int64(len(data))
+	l.total += size
+
+	switch {
+	case l.lim.MaxEntrySize > 0 && size > l.lim.MaxEntrySize:
+		return nil, ErrEntrySize
+	case l.lim.MaxTotalSize > 0 && l.total > l.lim.MaxTotalSize:
+		return nil, ErrTotalSize
+	case l.lim.MaxRatio > 0 && l.total > l.lim.MaxRatio*l.size:
+		return nil, ErrRatio
+	}
+
+	return data, nil
+}
+
+// readLimited reads at most max bytes from r, and returns errLimit if there is
+// more data to read. If max is negative, all data is read. If errLimit is nil,
+// max+1 bytes are returned when the limit is exceeded.
+func readLimited(r io.Reader, max int64, errLimit error) ([]byte, error) {
+	if max < 0 {
+		return ioutil.ReadAll(r)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > max && errLimit != nil {
+		return nil, errLimit
+	}
+
+	return data, nil
+}
+
+func minLimit(max int64, limit int64) int64 {
+	if limit < 0 {
+		limit = 0
+	}
+	if max < 0 || limit < max {
+		return limit
+	}
+
+	return max
+}
@@ -0,0 +1,104 @@
+package assets
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLimitsDownloadSize(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Assets.")
+	}))
+	defer svr.Close()
+
+	files, err := retrieve(svr.URL, &Opts{Limits: &Limits{MaxDownloadSize: 7}})
+	assertEqual(t, err, nil)
+	assertEqual(t, files[0].data, []byte("Assets."))
+
+	_, err = retrieve(svr.URL, &Opts{Limits: &Limits{MaxDownloadSize: 6}})
+	assertEqual(t, err, ErrDownloadSize)
+}
+
+func TestLimitsArchive(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+
+	f1, _ := w.Create("test/file1.txt")
+	f1.Write([]byte("File 1"))
+
+	f2, _ := w.Create("test/file2.txt")
+	f2.Write([]byte("File 2"))
+
+	w.Close()
+
+	files, err := processArchive(&Archive{Format: Zip}, buf.Bytes(),
+		&Opts{Limits: &Limits{MaxEntrySize: 6, MaxTotalSize: 12, MaxEntries: 2, MaxRatio: 1}})
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 2)
+
+	_, err = processArchive(&Archive{Format: Zip}, buf.Bytes(), &Opts{Limits: &Limits{MaxEntrySize: 5}})
+	assertEqual(t, err, ErrEntrySize)
+
+	_, err = processArchive(&Archive{Format: Zip}, buf.Bytes(), &Opts{Limits: &Limits{MaxTotalSize: 11}})
+	assertEqual(t, err, ErrTotalSize)
+
+	_, err = processArchive(&Archive{Format: Zip}, buf.Bytes(), &Opts{Limits: &Limits{MaxEntries: 1}})
+	assertEqual(t, err, ErrEntryCount)
+}
+
+func TestLimitsNested(t *testing.T) {
+	nbuf := new(bytes.Buffer)
+	nw := zip.NewWriter(nbuf)
+
+	f1, _ := nw.Create("file1.txt")
+	f1.Write([]byte("File 1"))
+
+	f2, _ := nw.Create("file2.txt")
+	f2.Write([]byte("File 2"))
+
+	nw.Close()
+
+	buf := new(bytes.Buffer)
+	w := zip.NewWriter(buf)
+	fn, _ := w.CreateHeader(&zip.FileHeader{Name: "nested.zip", Method: zip.Store})
+	fn.Write(nbuf.Bytes())
+
+	w.Close()
+
+	arch := &Archive{Format: Zip, Nested: &Nested{Member: "nested.zip", Archive: &Archive{Format: Zip}}}
+	size := int64(nbuf.Len())
+
+	files, err := processArchive(arch, buf.Bytes(), &Opts{Limits: &Limits{MaxTotalSize: size + 12, MaxEntries: 3}})
+	assertEqual(t, err, nil)
+	assertEqual(t, len(files), 2)
+
+	// The limits apply to the nested archives together with their parents
+	_, err = processArchive(arch, buf.Bytes(), &Opts{Limits: &Limits{MaxTotalSize: size + 11}})
+	assertEqual(t, err, &ArchiveError{"nested.zip", ErrTotalSize})
+
+	_, err = processArchive(arch, buf.Bytes(), &Opts{Limits: &Limits{MaxEntries: 2}})
+	assertEqual(t, err, &ArchiveError{"nested.zip", ErrEntryCount})
+}
+
+func TestLimitsRatio(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := gzip.NewWriter(buf)
+	w.Write([]byte(strings.Repeat("0", 100000)))
+	w.Close()
+
+	data, err := decompress(&Decompress{Gzip}, buf.Bytes(), &Limits{MaxRatio: 1000})
+	assertEqual(t, err, nil)
+	assertEqual(t, len(data), 100000)
+
+	_, err = decompress(&Decompress{Gzip}, buf.Bytes(), &Limits{MaxRatio: 10})
+	assertEqual(t, err, ErrRatio)
+
+	_, err = decompress(&Decompress{Gzip}, buf.Bytes(), &Limits{MaxEntrySize: 1000})
+	assertEqual(t, err, ErrEntrySize)
+}
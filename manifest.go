@@ -0,0 +1,74 @@
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"time"
+)
+
+// Manifest lists the asset files, in path order.
+type Manifest struct {
+	Assets []ManifestEntry `json:"assets"`
+}
+
+// ManifestEntry describes an asset file and its originating asset source.
+type ManifestEntry struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	SHA256   string    `json:"sha256"`
+	ModTime  time.Time `json:"modTime"`
+	Location string    `json:"location"`
+}
+
+// RetrieveManifest retrieves and processes the specified asset sources, and
+// returns their manifest.
+func RetrieveManifest(sources []*Source, opts *Opts) (*Manifest, error) {
+	if opts == nil {
+		opts = &Opts{}
+	}
+
+	assets, err := retrieveAssets(sources, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return newManifest(assets), nil
+}
+
+func newManifest(assets *assetFiles) *Manifest {
+	paths := []string{}
+	for fp, f := range assets.files {
+		if !f.mode.IsDir() {
+			paths = append(paths, fp)
+		}
+	}
+	sort.Strings(paths)
+
+	m := &Manifest{[]ManifestEntry{}}
+	for _, fp := range paths {
+		f := assets.files[fp]
+		sum := sha256.Sum256(f.data)
+		m.Assets = append(m.Assets, ManifestEntry{
+			Path:     fp,
+			Size:     int64(len(f.data)),
+			SHA256:   hex.EncodeToString(sum[:]),
+			ModTime:  f.modTime,
+			Location: assets.origins[fp],
+		})
+	}
+
+	return m
+}
+
+// writeManifest writes the manifest of the assets as JSON.
+func writeManifest(filePath string, assets *assetFiles) error {
+	data, err := json.MarshalIndent(newManifest(assets), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filePath, append(data, '\n'), 0644)
+}
@@ -0,0 +1,88 @@
+package assets
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManifest(t *testing.T) {
+	mt := time.Unix(1300000000, 0)
+
+	assets := newAssetFiles(FailOnConflict)
+	assets.add(&file{"test/b.txt", []byte("B"), mt, 0644}, "b.zip")
+	assets.add(&file{"/test/a.txt", []byte("A"), mt, 0644}, "a.txt")
+	assets.add(&file{"test/empty", nil, mt, os.ModeDir | 0755}, "b.zip")
+
+	m := newManifest(assets)
+	assertEqual(t, m, &Manifest{[]ManifestEntry{
+		{"test/a.txt", 1, "559aead08264d5795d3909718cdd05abd49572e84fe55590eef31a88a08fdffd", mt, "a.txt"},
+		{"test/b.txt", 1, "df7e70e5021544f4834bbee64a9e3789febc4be81470df629cad6ddb03320a5c", mt, "b.zip"},
+	}})
+}
+
+func TestRetrieveManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "assets.txt"), []byte("Assets."), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loc := filepath.Join(dir, "assets.txt")
+	sources := []*Source{{Path: "static/assets.txt", Location: loc}}
+
+	m, err := RetrieveManifest(sources, nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(m.Assets), 1)
+	assertEqual(t, m.Assets[0].Path, "static/assets.txt")
+	assertEqual(t, m.Assets[0].Size, int64(7))
+	assertEqual(t, m.Assets[0].Location, loc)
+
+	_, err = RetrieveManifest([]*Source{{Path: "missing.txt", Location: filepath.Join(dir, "missing.txt")}}, nil)
+	assertNotEqual(t, err, nil)
+}
+
+func TestCompileManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mt := time.Unix(1300000000, 0).UTC()
+	sources := []*Source{{Path: "retrieve_test.go", Location: "retrieve_test.go", ModTime: &mt}}
+	filePath := filepath.Join(dir, "assets.go")
+	manifestPath := filepath.Join(dir, "assets.json")
+
+	err = Compile(sources, filePath, "assets", "fs", &Opts{ManifestPath: manifestPath})
+	assertEqual(t, err, nil)
+
+	data, err := ioutil.ReadFile(manifestPath)
+	assertEqual(t, err, nil)
+
+	m := &Manifest{}
+	err = json.Unmarshal(data, m)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(m.Assets), 1)
+	assertEqual(t, m.Assets[0].Path, "retrieve_test.go")
+	assertEqual(t, m.Assets[0].ModTime, mt)
+	assertEqual(t, m.Assets[0].Location, "retrieve_test.go")
+
+	// A missing manifest is written again
+	err = os.Remove(manifestPath)
+	assertEqual(t, err, nil)
+
+	err = Compile(sources, filePath, "assets", "fs", &Opts{ManifestPath: manifestPath})
+	assertEqual(t, err, nil)
+
+	_, err = os.Stat(manifestPath)
+	assertEqual(t, err, nil)
+}
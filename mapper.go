@@ -0,0 +1,132 @@
+package assets
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ReMap returns a PathMapper that compares file paths to the input pattern
+// and maps matches to the replacement string (see Regexp.ReplaceAllString).
+// It panics if the pattern is invalid; see CompileReMap.
+func ReMap(pattern string, replacement string) PathMapper {
+	return reMap(regexp.MustCompile(pattern), replacement)
+}
+
+// CompileReMap is like ReMap, but returns an error if the pattern is invalid.
+func CompileReMap(pattern string, replacement string) (PathMapper, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return reMap(re, replacement), nil
+}
+
+func reMap(re *regexp.Regexp, replacement string) PathMapper {
+	return func(filePath string) string {
+		if !re.MatchString(filePath) {
+			return ""
+		}
+		return re.ReplaceAllString(filePath, replacement)
+	}
+}
+
+// GlobMap returns a PathMapper that keeps the file paths matching the glob
+// pattern (see path.Match) unchanged, and drops all other files. It returns
+// path.ErrBadPattern if the pattern is invalid.
+func GlobMap(pattern string) (PathMapper, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	return func(filePath string) string {
+		if ok, _ := path.Match(pattern, filePath); !ok {
+			return ""
+		}
+		return filePath
+	}, nil
+}
+
+// PathFilter selects asset files by their paths, such as the files of
+// Opts.HashNames and Opts.Precompress.
+type PathFilter func(string) bool
+
+// GlobFilter returns a PathFilter that selects the file paths matching the
+// glob pattern (see path.Match). It returns path.ErrBadPattern if the pattern
+// is invalid.
+func GlobFilter(pattern string) (PathFilter, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	return func(filePath string) bool {
+		ok, _ := path.Match(pattern, filePath)
+		return ok
+	}, nil
+}
+
+// KeptBy returns a PathFilter that selects the file paths kept by the mapper.
+func KeptBy(mapper PathMapper) PathFilter {
+	return func(filePath string) bool {
+		return mapper(filePath) != ""
+	}
+}
+
+// StripPrefix returns a PathMapper that removes the prefix from the file
+// paths, and drops the files without the prefix.
+func StripPrefix(prefix string) PathMapper {
+	return func(filePath string) string {
+		if !strings.HasPrefix(filePath, prefix) {
+			return ""
+		}
+		return strings.TrimPrefix(filePath, prefix)
+	}
+}
+
+// AddPrefix returns a PathMapper that adds the prefix to the file paths.
+func AddPrefix(prefix string) PathMapper {
+	return func(filePath string) string {
+		return prefix + filePath
+	}
+}
+
+// Chain returns a PathMapper that invokes the mappers in order, and returns
+// the first path that is not "". If all mappers return "", the file is
+// dropped.
+func Chain(mappers ...PathMapper) PathMapper {
+	return func(filePath string) string {
+		for _, mapper := range mappers {
+			if mapped := mapper(filePath); mapped != "" {
+				return mapped
+			}
+		}
+		return ""
+	}
+}
+
+// Pipeline returns a PathMapper that invokes the mappers in order, passing
+// the path returned by each mapper to the next one. If any of the mappers
+// returns "", the file is dropped.
+func Pipeline(mappers ...PathMapper) PathMapper {
+	return func(filePath string) string {
+		for _, mapper := range mappers {
+			filePath = mapper(filePath)
+			if filePath == "" {
+				return ""
+			}
+		}
+		return filePath
+	}
+}
+
+// Exclude returns a PathMapper that drops the files kept by the mapper, and
+// keeps all other files unchanged.
+func Exclude(mapper PathMapper) PathMapper {
+	return func(filePath string) string {
+		if mapper(filePath) != "" {
+			return ""
+		}
+		return filePath
+	}
+}
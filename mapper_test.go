@@ -0,0 +1,71 @@
+package assets
+
+import (
+	"path"
+	"regexp/syntax"
+	"testing"
+)
+
+func TestMapperReMap(t *testing.T) {
+	mapper, err := CompileReMap("^test/(.*)\\.txt$", "${1}.md")
+	assertEqual(t, err, nil)
+	assertEqual(t, mapper("test/file1.txt"), "file1.md")
+	assertEqual(t, mapper("test/file1.js"), "")
+
+	_, err = CompileReMap("(test", "")
+	assertEqual(t, err.(*syntax.Error).Code, syntax.ErrMissingParen)
+}
+
+func TestMapperGlobMap(t *testing.T) {
+	mapper, err := GlobMap("test/*.txt")
+	assertEqual(t, err, nil)
+	assertEqual(t, mapper("test/file1.txt"), "test/file1.txt")
+	assertEqual(t, mapper("test/dir/file1.txt"), "")
+
+	_, err = GlobMap("[test")
+	assertEqual(t, err, path.ErrBadPattern)
+}
+
+func TestMapperPrefix(t *testing.T) {
+	strip := StripPrefix("dist/")
+	assertEqual(t, strip("dist/file1.txt"), "file1.txt")
+	assertEqual(t, strip("src/file1.txt"), "")
+
+	add := AddPrefix("static/")
+	assertEqual(t, add("file1.txt"), "static/file1.txt")
+}
+
+func TestMapperChain(t *testing.T) {
+	mapper := Chain(StripPrefix("dist/"), ReMap("^src/(.*\\.css)$", "css/${1}"))
+	assertEqual(t, mapper("dist/file1.txt"), "file1.txt")
+	assertEqual(t, mapper("src/main.css"), "css/main.css")
+	assertEqual(t, mapper("src/main.js"), "")
+}
+
+func TestMapperPipeline(t *testing.T) {
+	glob, _ := GlobMap("dist/*.js")
+	mapper := Pipeline(glob, StripPrefix("dist/"), AddPrefix("js/"))
+	assertEqual(t, mapper("dist/main.js"), "js/main.js")
+	assertEqual(t, mapper("dist/main.css"), "")
+}
+
+func TestMapperExclude(t *testing.T) {
+	glob, _ := GlobMap("*.map")
+	mapper := Exclude(glob)
+	assertEqual(t, mapper("main.js"), "main.js")
+	assertEqual(t, mapper("main.js.map"), "")
+}
+
+func TestMapperFilter(t *testing.T) {
+	filter, err := GlobFilter("*.css")
+	assertEqual(t, err, nil)
+	assertEqual(t, filter("main.css"), true)
+	assertEqual(t, filter("css/main.css"), false)
+
+	_, err = GlobFilter("[test")
+	assertEqual(t, err, path.ErrBadPattern)
+
+	kept := KeptBy(StripPrefix("dist/"))
+	assertEqual(t, kept("dist/main.js"), true)
+	assertEqual(t, kept("src/main.js"), false)
+}
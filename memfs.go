@@ -0,0 +1,178 @@
+package assets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"time"
+)
+
+// memFS is an in-memory http.FileSystem serving the asset files.
+type memFS struct {
+	files    map[string]*file
+	dirs     map[string][]string
+	sums     map[string]string
+	variants map[string]map[string]string
+}
+
+func newMemFS(files map[string]*file, variants map[string]map[string]string) *memFS {
+	m := &memFS{map[string]*file{}, map[string][]string{}, map[string]string{}, map[string]map[string]string{}}
+	children := map[string]map[string]bool{"/": {}}
+
+	for fp, vs := range variants {
+		fp = path.Clean("/" + fp)
+		m.variants[fp] = map[string]string{}
+		for enc, vp := range vs {
+			m.variants[fp][enc] = path.Clean("/" + vp)
+		}
+	}
+
+	for fp, f := range files {
+		fp = path.Clean("/" + fp)
+		m.files[fp] = f
+
+		if !f.mode.IsDir() {
+			sum := sha256.Sum256(f.data)
+			m.sums[fp] = hex.EncodeToString(sum[:])
+		}
+
+		if f.mode.IsDir() && children[fp] == nil {
+			children[fp] = map[string]bool{}
+		}
+
+		for ; fp != "/"; fp = path.Dir(fp) {
+			dir := path.Dir(fp)
+			if children[dir] == nil {
+				children[dir] = map[string]bool{}
+			}
+			children[dir][path.Base(fp)] = true
+		}
+	}
+
+	for dir, names := range children {
+		for name := range names {
+			m.dirs[dir] = append(m.dirs[dir], name)
+		}
+		sort.Strings(m.dirs[dir])
+	}
+
+	return m
+}
+
+func (m *memFS) Open(name string) (http.File, error) {
+	fp := path.Clean("/" + name)
+
+	info, err := m.stat(fp)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	if info.IsDir() {
+		return &memFile{bytes.NewReader(nil), info, m, fp, 0}, nil
+	}
+
+	return &memFile{bytes.NewReader(m.files[fp].data), info, m, fp, 0}, nil
+}
+
+func (m *memFS) stat(fp string) (os.FileInfo, error) {
+	if _, ok := m.dirs[fp]; ok {
+		if f, ok := m.files[fp]; ok && f.mode.IsDir() {
+			return &fileInfo{path.Base(fp), 0, f.mode, f.modTime}, nil
+		}
+		return &fileInfo{path.Base(fp), 0, os.ModeDir | 0755, time.Time{}}, nil
+	}
+
+	if f, ok := m.files[fp]; ok {
+		return &fileInfo{path.Base(fp), int64(len(f.data)), f.mode, f.modTime}, nil
+	}
+
+	return nil, os.ErrNotExist
+}
+
+// memFile is a file or directory opened from a memFS.
+type memFile struct {
+	*bytes.Reader
+	info os.FileInfo
+	fs   *memFS
+	path string
+	pos  int
+}
+
+func (f *memFile) Close() error {
+	return nil
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	return f.info, nil
+}
+
+// SHA256 returns the hex encoded SHA-256 digest of the file contents,
+// calculated when the file system was created.
+func (f *memFile) SHA256() string {
+	return f.fs.sums[f.path]
+}
+
+func (f *memFile) Readdir(count int) ([]os.FileInfo, error) {
+	if !f.info.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: f.path, Err: os.ErrInvalid}
+	}
+
+	names := f.fs.dirs[f.path][f.pos:]
+	if count > 0 {
+		if len(names) == 0 {
+			return nil, io.EOF
+		}
+		if len(names) > count {
+			names = names[:count]
+		}
+	}
+
+	infos := []os.FileInfo{}
+	for _, name := range names {
+		info, err := f.fs.stat(path.Join(f.path, name))
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+
+	f.pos += len(names)
+	return infos, nil
+}
+
+// fileInfo describes a file or directory in a memFS.
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (i *fileInfo) Name() string {
+	return i.name
+}
+
+func (i *fileInfo) Size() int64 {
+	return i.size
+}
+
+func (i *fileInfo) Mode() os.FileMode {
+	return i.mode
+}
+
+func (i *fileInfo) ModTime() time.Time {
+	return i.modTime
+}
+
+func (i *fileInfo) IsDir() bool {
+	return i.mode.IsDir()
+}
+
+func (i *fileInfo) Sys() interface{} {
+	return nil
+}
@@ -0,0 +1,105 @@
+package assets
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMemFS(t *testing.T) {
+	mt1 := time.Unix(1300000000, 0)
+	mt2 := time.Unix(1400000000, 0)
+	mt3 := time.Unix(1500000000, 0)
+
+	fs := newMemFS(map[string]*file{
+		"test/file1.txt":     {"test/file1.txt", []byte("File 1"), mt1, 0644},
+		"test/run.sh":        {"test/run.sh", []byte("File 2"), mt2, 0755},
+		"test/dir/file3.txt": {"test/dir/file3.txt", []byte("File 3"), mt3, 0600},
+	}, nil)
+
+	file1, err := fs.Open("test/file1.txt")
+	assertEqual(t, err, nil)
+	fdata1, err := ioutil.ReadAll(file1)
+	assertEqual(t, err, nil)
+	assertEqual(t, fdata1, []byte("File 1"))
+	fstat1, err := file1.Stat()
+	assertEqual(t, err, nil)
+	assertEqual(t, fstat1.Name(), "file1.txt")
+	assertEqual(t, fstat1.Size(), int64(6))
+	assertEqual(t, fstat1.Mode(), os.FileMode(0644))
+	assertEqual(t, fstat1.ModTime(), mt1)
+	assertEqual(t, fstat1.IsDir(), false)
+
+	file2, err := fs.Open("/test/run.sh")
+	assertEqual(t, err, nil)
+	fstat2, err := file2.Stat()
+	assertEqual(t, err, nil)
+	assertEqual(t, fstat2.Mode(), os.FileMode(0755))
+
+	_, err = file2.Readdir(0)
+	assertNotEqual(t, err, nil)
+
+	_, err = fs.Open("test/file4.txt")
+	assertEqual(t, os.IsNotExist(err), true)
+}
+
+func TestMemFSReaddir(t *testing.T) {
+	fs := newMemFS(map[string]*file{
+		"test/file1.txt":     {"test/file1.txt", []byte("File 1"), time.Time{}, 0644},
+		"test/file2.txt":     {"test/file2.txt", []byte("File 2"), time.Time{}, 0644},
+		"test/dir/file3.txt": {"test/dir/file3.txt", []byte("File 3"), time.Time{}, 0644},
+	}, nil)
+
+	root, err := fs.Open("/")
+	assertEqual(t, err, nil)
+	infos, err := root.Readdir(0)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(infos), 1)
+	assertEqual(t, infos[0].Name(), "test")
+	assertEqual(t, infos[0].IsDir(), true)
+
+	dir, err := fs.Open("test")
+	assertEqual(t, err, nil)
+
+	infos, err = dir.Readdir(2)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(infos), 2)
+	assertEqual(t, infos[0].Name(), "dir")
+	assertEqual(t, infos[0].Mode(), os.ModeDir|0755)
+	assertEqual(t, infos[1].Name(), "file1.txt")
+
+	infos, err = dir.Readdir(2)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(infos), 1)
+	assertEqual(t, infos[0].Name(), "file2.txt")
+
+	_, err = dir.Readdir(2)
+	assertEqual(t, err, io.EOF)
+}
+
+func TestMemFSDirs(t *testing.T) {
+	mt1 := time.Unix(1300000000, 0)
+
+	fs := newMemFS(map[string]*file{
+		"test/file1.txt": {"test/file1.txt", []byte("File 1"), time.Time{}, 0644},
+		"test/empty":     {"test/empty", nil, mt1, os.ModeDir | 0700},
+	}, nil)
+
+	dir, err := fs.Open("test")
+	assertEqual(t, err, nil)
+	infos, err := dir.Readdir(0)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(infos), 2)
+	assertEqual(t, infos[0].Name(), "empty")
+	assertEqual(t, infos[0].Mode(), os.ModeDir|0700)
+	assertEqual(t, infos[0].ModTime(), mt1)
+	assertEqual(t, infos[1].Name(), "file1.txt")
+
+	empty, err := fs.Open("test/empty")
+	assertEqual(t, err, nil)
+	infos, err = empty.Readdir(0)
+	assertEqual(t, err, nil)
+	assertEqual(t, len(infos), 0)
+}
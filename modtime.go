@@ -0,0 +1,68 @@
+package assets
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ModTimePolicy enumerates the ways of setting the modification times of the
+// assets.
+type ModTimePolicy int
+
+const (
+	// KeepModTime keeps the modification times of the retrieved files.
+	KeepModTime = iota
+	// FixedModTime sets the modification times to Opts.FixedTime, or if it
+	// is not set, to the time in the SOURCE_DATE_EPOCH environment variable.
+	FixedModTime
+	// ZeroModTime sets the modification times to the zero time.
+	ZeroModTime
+)
+
+var (
+	// ErrFixedTime is returned when FixedModTime is requested, but neither
+	// Opts.FixedTime nor a valid SOURCE_DATE_EPOCH is set
+	ErrFixedTime = errors.New("no fixed modification time")
+)
+
+// fixedModTime returns the modification time to use for all assets, or nil
+// if the modification times are kept.
+func fixedModTime(opts *Opts) (*time.Time, error) {
+	switch opts.ModTime {
+	case FixedModTime:
+		if !opts.FixedTime.IsZero() {
+			return &opts.FixedTime, nil
+		}
+		if t, ok := sourceDateEpoch(); ok {
+			return &t, nil
+		}
+		return nil, ErrFixedTime
+	case ZeroModTime:
+		return &time.Time{}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// sourceDateEpoch returns the time in the SOURCE_DATE_EPOCH environment
+// variable (see https://reproducible-builds.org/specs/source-date-epoch/).
+func sourceDateEpoch() (time.Time, bool) {
+	epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(epoch, 0).UTC(), true
+}
+
+// now returns the time used for files without a modification time. It
+// honours SOURCE_DATE_EPOCH, so builds can be reproduced.
+func now() time.Time {
+	if t, ok := sourceDateEpoch(); ok {
+		return t
+	}
+
+	return time.Now()
+}
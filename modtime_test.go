@@ -0,0 +1,39 @@
+package assets
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestModTimeKeep(t *testing.T) {
+	fixed, err := fixedModTime(&Opts{})
+	assertEqual(t, err, nil)
+	assertEqual(t, fixed, (*time.Time)(nil))
+}
+
+func TestModTimeFixed(t *testing.T) {
+	os.Unsetenv("SOURCE_DATE_EPOCH")
+
+	mt := time.Unix(1300000000, 0)
+	fixed, err := fixedModTime(&Opts{ModTime: FixedModTime, FixedTime: mt})
+	assertEqual(t, err, nil)
+	assertEqual(t, *fixed, mt)
+
+	_, err = fixedModTime(&Opts{ModTime: FixedModTime})
+	assertEqual(t, err, ErrFixedTime)
+
+	os.Setenv("SOURCE_DATE_EPOCH", "1400000000")
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+
+	fixed, err = fixedModTime(&Opts{ModTime: FixedModTime})
+	assertEqual(t, err, nil)
+	assertEqual(t, *fixed, time.Unix(1400000000, 0).UTC())
+	assertEqual(t, now(), time.Unix(1400000000, 0).UTC())
+}
+
+func TestModTimeZero(t *testing.T) {
+	fixed, err := fixedModTime(&Opts{ModTime: ZeroModTime})
+	assertEqual(t, err, nil)
+	assertEqual(t, *fixed, time.Time{})
+}
@@ -0,0 +1,134 @@
+package assets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"sort"
+
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	// BrotliEncoding is the content coding of the brotli compressed variants.
+	BrotliEncoding = "br"
+	// GzipEncoding is the content coding of the gzip compressed variants.
+	GzipEncoding = "gzip"
+)
+
+// encodings lists the content codings of the precompressed variants in order
+// of preference, and the suffixes of their paths.
+var encodings = []struct {
+	name   string
+	suffix string
+}{
+	{BrotliEncoding, ".br"},
+	{GzipEncoding, ".gz"},
+}
+
+// EncodedFile is implemented by the files of the file systems returned by
+// Retrieve, RetrieveWithOpts and RetrieveFS, so the precompressed variants
+// of Opts.Precompress can be served without compressing them again.
+type EncodedFile interface {
+	// Encodings returns the content codings of the precompressed variants of
+	// the file, in order of preference.
+	Encodings() []string
+
+	// EncodedBytes returns the contents of the variant in the content
+	// coding, or false if there is no such variant.
+	EncodedBytes(encoding string) ([]byte, bool)
+}
+
+// precompress adds the precompressed variants of the asset files selected by
+// the filter. Variants that are not smaller than the file are not added.
+func (a *assetFiles) precompress(filter PathFilter) error {
+	paths := []string{}
+	for fp, f := range a.files {
+		if !f.mode.IsDir() && filter(fp) {
+			paths = append(paths, fp)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, fp := range paths {
+		f := a.files[fp]
+		origin := a.origins[fp]
+
+		for _, enc := range encodings {
+			data, err := encode(enc.name, f.data)
+			if err != nil {
+				return err
+			}
+			if len(data) >= len(f.data) {
+				continue
+			}
+
+			v := &file{fp + enc.suffix, data, f.modTime, f.mode}
+			if err := a.add(v, origin); err != nil {
+				return err
+			}
+
+			// Record the variant at its final path, unless it was skipped
+			if a.files[v.path] == v {
+				if a.variants[fp] == nil {
+					a.variants[fp] = map[string]string{}
+				}
+				a.variants[fp][enc.name] = v.path
+			}
+		}
+	}
+
+	return nil
+}
+
+// encode compresses the data with the best compression of the content
+// coding.
+func encode(encoding string, data []byte) ([]byte, error) {
+	var b bytes.Buffer
+
+	switch encoding {
+	case BrotliEncoding:
+		w := brotli.NewWriterLevel(&b, brotli.BestCompression)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case GzipEncoding:
+		w, err := gzip.NewWriterLevel(&b, gzip.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+func (f *memFile) Encodings() []string {
+	names := []string{}
+	for _, enc := range encodings {
+		if _, ok := f.EncodedBytes(enc.name); ok {
+			names = append(names, enc.name)
+		}
+	}
+
+	return names
+}
+
+func (f *memFile) EncodedBytes(encoding string) ([]byte, bool) {
+	if f.info.IsDir() {
+		return nil, false
+	}
+
+	if vp, ok := f.fs.variants[f.path][encoding]; ok {
+		return f.fs.files[vp].data, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,153 @@
+package assets
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestEncode(t *testing.T) {
+	data := []byte(strings.Repeat("Assets. ", 100))
+
+	gz, err := encode(GzipEncoding, data)
+	assertEqual(t, err, nil)
+	dec, err := decompress(&Decompress{Gzip}, gz, nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, dec, data)
+
+	br, err := encode(BrotliEncoding, data)
+	assertEqual(t, err, nil)
+	dec, err = ioutil.ReadAll(brotli.NewReader(bytes.NewReader(br)))
+	assertEqual(t, err, nil)
+	assertEqual(t, dec, data)
+
+	// Same data always results in the same variant
+	gz2, err := encode(GzipEncoding, data)
+	assertEqual(t, err, nil)
+	assertEqual(t, gz2, gz)
+}
+
+func TestPrecompress(t *testing.T) {
+	mt := time.Unix(1300000000, 0)
+	text := []byte(strings.Repeat("Assets. ", 100))
+
+	assets := newAssetFiles(FailOnConflict)
+	assets.add(&file{"app.css", text, mt, 0644}, "app.zip")
+	assets.add(&file{"small.css", []byte("A"), mt, 0644}, "app.zip")
+	assets.add(&file{"app.png", text, mt, 0644}, "app.zip")
+
+	css, err := GlobFilter("*.css")
+	assertEqual(t, err, nil)
+	err = assets.precompress(css)
+	assertEqual(t, err, nil)
+
+	assertEqual(t, len(assets.files), 5)
+	assertEqual(t, assets.files["app.css.gz"].modTime, mt)
+	assertEqual(t, assets.origins["app.css.gz"], "app.zip")
+	assertNotEqual(t, assets.files["app.css.br"], (*file)(nil))
+	assertEqual(t, assets.files["small.css.gz"], (*file)(nil))
+	assertEqual(t, assets.files["app.png.gz"], (*file)(nil))
+	assertEqual(t, assets.variants, map[string]map[string]string{
+		"app.css": {BrotliEncoding: "app.css.br", GzipEncoding: "app.css.gz"},
+	})
+
+	// Variants conflicting with other assets
+	assets = newAssetFiles(FailOnConflict)
+	assets.add(&file{"app.css", text, mt, 0644}, "app.zip")
+	assets.add(&file{"app.css.br", text, mt, 0644}, "other.zip")
+
+	err = assets.precompress(css)
+	assertEqual(t, err, &ConflictError{"app.css.br", "other.zip", "app.zip"})
+
+	// Variants renamed or skipped on conflicts
+	assets = newAssetFiles(RenameDuplicates)
+	assets.add(&file{"app.css", text, mt, 0644}, "app.zip")
+	assets.add(&file{"app.css.br", text, mt, 0644}, "other.zip")
+
+	err = assets.precompress(css)
+	assertEqual(t, err, nil)
+	assertEqual(t, assets.files["app.css.br"].data, text)
+	assertEqual(t, assets.variants["app.css"][BrotliEncoding], "app.css-1.br")
+	assertNotEqual(t, assets.files["app.css-1.br"], (*file)(nil))
+
+	assets = newAssetFiles(FirstWins)
+	assets.add(&file{"app.css", text, mt, 0644}, "app.zip")
+	assets.add(&file{"app.css.br", text, mt, 0644}, "other.zip")
+
+	err = assets.precompress(css)
+	assertEqual(t, err, nil)
+	assertEqual(t, assets.files["app.css.br"].data, text)
+	assertEqual(t, assets.variants["app.css"], map[string]string{GzipEncoding: "app.css.gz"})
+}
+
+func TestRetrievePrecompress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	text := []byte(strings.Repeat("Assets. ", 100))
+	err = ioutil.WriteFile(filepath.Join(dir, "app.css"), text, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	css, err := GlobFilter("*/*.css")
+	assertEqual(t, err, nil)
+
+	sources := []*Source{{Path: "static/app.css", Location: filepath.Join(dir, "app.css")}}
+	opts := &Opts{Precompress: css}
+
+	hfs, err := RetrieveWithOpts(sources, opts)
+	assertEqual(t, err, nil)
+
+	file, err := hfs.Open("/static/app.css")
+	assertEqual(t, err, nil)
+	enc, ok := file.(EncodedFile)
+	assertEqual(t, ok, true)
+	assertEqual(t, enc.Encodings(), []string{BrotliEncoding, GzipEncoding})
+
+	gz, ok := enc.EncodedBytes(GzipEncoding)
+	assertEqual(t, ok, true)
+	dec, err := decompress(&Decompress{Gzip}, gz, nil)
+	assertEqual(t, err, nil)
+	assertEqual(t, dec, text)
+
+	_, ok = enc.EncodedBytes("deflate")
+	assertEqual(t, ok, false)
+
+	dir1, err := hfs.Open("/static")
+	assertEqual(t, err, nil)
+	assertEqual(t, dir1.(EncodedFile).Encodings(), []string{})
+
+	// Files at the paths of variants are not variants themselves
+	err = ioutil.WriteFile(filepath.Join(dir, "data.txt.gz"), text, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := RetrieveWithOpts([]*Source{
+		{Path: "data.txt", Location: filepath.Join(dir, "app.css")},
+		{Path: "data.txt.gz", Location: filepath.Join(dir, "data.txt.gz")},
+	}, opts)
+	assertEqual(t, err, nil)
+
+	file3, err := data.Open("/data.txt")
+	assertEqual(t, err, nil)
+	assertEqual(t, file3.(EncodedFile).Encodings(), []string{})
+	_, ok = file3.(EncodedFile).EncodedBytes(GzipEncoding)
+	assertEqual(t, ok, false)
+
+	fsys, err := RetrieveFS(sources, opts)
+	assertEqual(t, err, nil)
+
+	file2, err := fsys.Open("static/app.css")
+	assertEqual(t, err, nil)
+	assertEqual(t, file2.(EncodedFile).Encodings(), []string{BrotliEncoding, GzipEncoding})
+}
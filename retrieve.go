@@ -6,8 +6,8 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
-	"time"
 )
 
 var (
@@ -15,19 +15,23 @@ var (
 	ErrNoMatch = errors.New("no match")
 )
 
-func retrieve(loc string) ([]*file, error) {
-	if strings.HasPrefix(loc, "http://") || strings.HasPrefix(loc, "https://") {
-		return retrieveHTTP(loc)
+func retrieve(loc string, opts *Opts) ([]*file, error) {
+	if opts == nil {
+		opts = &Opts{}
+	}
+
+	if isHTTP(loc) {
+		return retrieveHTTP(loc, opts.Limits)
 	}
 
 	if hasMeta(loc) {
-		return retrieveGlob(loc)
+		return retrieveGlob(loc, opts.KeepDirs)
 	}
 
 	return retrieveFile(loc)
 }
 
-func retrieveHTTP(url string) ([]*file, error) {
+func retrieveHTTP(url string, lim *Limits) ([]*file, error) {
 	resp, err := http.Get(url)
 	if err != nil {
 		return nil, err
@@ -35,20 +39,28 @@ func retrieveHTTP(url string) ([]*file, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("http status: " + string(resp.StatusCode))
+		return nil, errors.New("http status: " + strconv.Itoa(resp.StatusCode))
+	}
+
+	max := int64(-1)
+	if lim != nil && lim.MaxDownloadSize > 0 {
+		max = lim.MaxDownloadSize
+		if resp.ContentLength > max {
+			return nil, ErrDownloadSize
+		}
 	}
 
-	data, err := ioutil.ReadAll(resp.Body)
+	data, err := readLimited(resp.Body, max, ErrDownloadSize)
 	if err != nil {
 		return nil, err
 	}
 
 	modTime, err := http.ParseTime(resp.Header.Get("Last-Modified"))
 	if err != nil {
-		modTime = time.Now()
+		modTime = now()
 	}
 
-	return []*file{&file{url, data, modTime}}, nil
+	return []*file{&file{url, data, modTime, 0644}}, nil
 }
 
 func retrieveFile(loc string) ([]*file, error) {
@@ -63,16 +75,18 @@ func retrieveFile(loc string) ([]*file, error) {
 		return nil, err
 	}
 
-	modTime := time.Now()
+	modTime := now()
+	mode := os.FileMode(0644)
 	info, err := f.Stat()
 	if err == nil {
 		modTime = info.ModTime()
+		mode = info.Mode()
 	}
 
-	return []*file{&file{loc, data, modTime}}, nil
+	return []*file{&file{loc, data, modTime, mode}}, nil
 }
 
-func retrieveGlob(loc string) ([]*file, error) {
+func retrieveGlob(loc string, keepDirs bool) ([]*file, error) {
 	// find longest prefix not containing globs
 	dirs := strings.Split(loc, "/")
 	i := 0
@@ -99,6 +113,17 @@ func retrieveGlob(loc string) ([]*file, error) {
 	for _, match := range matches {
 		path := strings.TrimPrefix(filepath.ToSlash(match), root)
 
+		if keepDirs {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if info.IsDir() {
+				files = append(files, &file{path, nil, info.ModTime(), info.Mode()})
+				continue
+			}
+		}
+
 		f, err := os.Open(match)
 		if err != nil {
 			return nil, err
@@ -110,20 +135,31 @@ func retrieveGlob(loc string) ([]*file, error) {
 			return nil, err
 		}
 
-		modTime := time.Now()
+		modTime := now()
+		mode := os.FileMode(0644)
 		info, err := f.Stat()
 		if err == nil {
 			modTime = info.ModTime()
+			mode = info.Mode()
 		}
 
 		f.Close()
 
-		files = append(files, &file{path, data, modTime})
+		files = append(files, &file{path, data, modTime, mode})
 	}
 
 	return files, nil
 }
 
+func isHTTP(loc string) bool {
+	return strings.HasPrefix(loc, "http://") || strings.HasPrefix(loc, "https://")
+}
+
+// isGlob reports whether the location is retrieved as a glob pattern.
+func isGlob(loc string) bool {
+	return !isHTTP(loc) && hasMeta(loc)
+}
+
 func hasMeta(path string) bool {
 	return strings.ContainsAny(path, "*?[")
 }
@@ -18,37 +18,37 @@ func TestRetrieveHttp(t *testing.T) {
 		w.WriteHeader(http.StatusNotFound)
 	}))
 	defer svr.Close()
-	files, err := retrieve(strings.Join([]string{svr.URL, "/assets.txt"}, ""))
+	files, err := retrieve(strings.Join([]string{svr.URL, "/assets.txt"}, ""), nil)
 	assertEqual(t, err, nil)
 	assertEqual(t, len(files), 1)
 	assertEqual(t, files[0].data, []byte("Assets."))
 
-	_, err = retrieve(strings.Join([]string{svr.URL, "/xxxx"}, ""))
+	_, err = retrieve(strings.Join([]string{svr.URL, "/xxxx"}, ""), nil)
 	assertNotEqual(t, err, nil)
 
-	_, err = retrieve("http://invalid.u.r.l")
+	_, err = retrieve("http://invalid.u.r.l", nil)
 	assertNotEqual(t, err, nil)
 	assertEqual(t, strings.Contains(err.Error(), "http://invalid.u.r.l"), true)
 }
 
 func TestRetrieveFile(t *testing.T) {
-	files, err := retrieve("retrieve_test.go")
+	files, err := retrieve("retrieve_test.go", nil)
 	assertEqual(t, err, nil)
 	assertEqual(t, len(files), 1)
 	assertEqual(t, len(files[0].data) > 0, true)
 }
 
 func TestRetrieveFileNotExist(t *testing.T) {
-	_, err := retrieve("xxxx")
+	_, err := retrieve("xxxx", nil)
 	assertNotEqual(t, err, nil)
 }
 
 func TestRetrieveGlobNotExist(t *testing.T) {
-	_, err := retrieve("xxxx[0-9]xxxx")
+	_, err := retrieve("xxxx[0-9]xxxx", nil)
 	assertEqual(t, err, ErrNoMatch)
 }
 
 func TestRetrieveGlobInvalid(t *testing.T) {
-	_, err := retrieve("[xxxx[")
+	_, err := retrieve("[xxxx[", nil)
 	assertEqual(t, err, filepath.ErrBadPattern)
 }
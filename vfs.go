@@ -0,0 +1,160 @@
+package assets
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/shurcooL/vfsgen"
+)
+
+// modeFSSource is appended to the source file generated by vfsgen. It wraps
+// the file system generated by vfsgen, which reports mode 0444 for all
+// files, to report the file modes of the assets.
+const modeFSSource = `
+// assets۰ModeFS is a file system reporting the file modes of the assets.
+type assets۰ModeFS struct {
+	fs    http.FileSystem
+	modes map[string]os.FileMode
+}
+
+func (fs assets۰ModeFS) Open(path string) (http.File, error) {
+	f, err := fs.fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mf := &assets۰ModeFile{f, pathpkg.Clean("/" + path), fs.modes}
+	if gz, ok := f.(interface{ GzipBytes() []byte }); ok {
+		return &assets۰GzipModeFile{mf, gz}, nil
+	}
+	return mf, nil
+}
+
+// assets۰ModeFile is a file of assets۰ModeFS.
+type assets۰ModeFile struct {
+	http.File
+	path  string
+	modes map[string]os.FileMode
+}
+
+func (f *assets۰ModeFile) Stat() (os.FileInfo, error) {
+	fi, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return f.info(f.path, fi), nil
+}
+
+func (f *assets۰ModeFile) Readdir(count int) ([]os.FileInfo, error) {
+	fis, err := f.File.Readdir(count)
+	for i, fi := range fis {
+		fis[i] = f.info(pathpkg.Join(f.path, fi.Name()), fi)
+	}
+	return fis, err
+}
+
+func (f *assets۰ModeFile) info(path string, fi os.FileInfo) os.FileInfo {
+	if mode, ok := f.modes[path]; ok {
+		return assets۰ModeInfo{fi, mode}
+	}
+	return fi
+}
+
+// assets۰GzipModeFile is a compressed file of assets۰ModeFS.
+type assets۰GzipModeFile struct {
+	*assets۰ModeFile
+	gz interface{ GzipBytes() []byte }
+}
+
+func (f *assets۰GzipModeFile) GzipBytes() []byte {
+	return f.gz.GzipBytes()
+}
+
+// assets۰ModeInfo describes a file of assets۰ModeFS.
+type assets۰ModeInfo struct {
+	os.FileInfo
+	mode os.FileMode
+}
+
+func (fi assets۰ModeInfo) Mode() os.FileMode {
+	return fi.mode
+}
+`
+
+// compileVFS generates the source file with vfsgen, and wraps the generated
+// file system to report the file modes of the assets.
+func compileVFS(assets *assetFiles, filePath string, pkgName string, varName string, opts *Opts) error {
+	m := newMemFS(assets.files, assets.variants)
+
+	vfsName := "vfsgen۰" + varName
+	err := vfsgen.Generate(m, vfsgen.Options{
+		Filename:        filePath,
+		PackageName:     pkgName,
+		BuildTags:       opts.BuildTags,
+		VariableName:    vfsName,
+		VariableComment: fmt.Sprintf("%s is the file system generated by vfsgen, without the file modes.", vfsName),
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	b := bytes.NewBuffer(data)
+	b.WriteString("\n")
+	writeComment(b, opts.VariableComment)
+	fmt.Fprintf(b, "var %s http.FileSystem = assets۰ModeFS{%s, map[string]os.FileMode{\n", varName, vfsName)
+	writeModes(b, m)
+	fmt.Fprintf(b, "}}\n")
+	b.WriteString(modeFSSource)
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filePath, src, 0644)
+}
+
+// writeComment writes the text as a line comment of the generated source.
+func writeComment(b *bytes.Buffer, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(strings.TrimSpace("// "+line) + "\n")
+	}
+}
+
+// writeModes writes the entries of the mode table, with the modes of all files
+// and directories of the file system.
+func writeModes(b *bytes.Buffer, m *memFS) {
+	paths := []string{}
+	for fp := range m.dirs {
+		paths = append(paths, fp)
+	}
+	for fp, f := range m.files {
+		if !f.mode.IsDir() {
+			paths = append(paths, fp)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, fp := range paths {
+		info, err := m.stat(fp)
+		if err != nil {
+			continue
+		}
+
+		if mode := info.Mode(); mode.IsDir() {
+			fmt.Fprintf(b, "%q: os.ModeDir | %#o,\n", fp, uint32(mode&^os.ModeDir))
+		} else {
+			fmt.Fprintf(b, "%q: %#o,\n", fp, uint32(mode))
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package assets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompileModes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "run.sh"), []byte("Run."), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []*Source{
+		{Path: "bin/run.sh", Location: filepath.Join(dir, "run.sh")},
+	}
+	filePath := filepath.Join(dir, "assets.go")
+
+	err = Compile(sources, filePath, "assets", "Assets", &Opts{VariableComment: "Assets are the assets."})
+	assertEqual(t, err, nil)
+
+	src, err := ioutil.ReadFile(filePath)
+	assertEqual(t, err, nil)
+	assertEqual(t, strings.Contains(string(src), "var vfsgen۰Assets = func() http.FileSystem {\n"), true)
+	assertEqual(t, strings.Contains(string(src), "// Assets are the assets.\n"+
+		"var Assets http.FileSystem = assets۰ModeFS{vfsgen۰Assets, map[string]os.FileMode{\n"+
+		"\t\"/\":           os.ModeDir | 0755,\n"+
+		"\t\"/bin\":        os.ModeDir | 0755,\n"+
+		"\t\"/bin/run.sh\": 0755,\n"+
+		"}}\n"), true)
+	assertEqual(t, strings.Contains(string(src), "func (fi assets۰ModeInfo) Mode() os.FileMode {\n"), true)
+}